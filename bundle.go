@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/pflag"
+)
+
+// BuildChain orders certificates from the leaf up to the root by linking
+// issuers to subjects. The self-signed root is dropped unless withRoot is set.
+// Certificates not on the chain of the leaf, up to the root, are returned as
+// unused.
+func BuildChain(certs Bundle, withRoot bool) (chain, unused Bundle, err error) {
+	certs = dedupe(certs)
+	if len(certs) == 0 {
+		return nil, nil, errors.New("no certificates")
+	}
+
+	// The leaf issued nothing. Prefer non-CA certificates if there are
+	// several candidates, like a leaf with an unrelated CA.
+	var leaves Bundle
+	for _, c := range certs {
+		issued := false
+		for _, other := range certs {
+			if other != c && issuerOf(other, Bundle{c}) != nil {
+				issued = true
+				break
+			}
+		}
+		if !issued {
+			leaves = append(leaves, c)
+		}
+	}
+	if len(leaves) > 1 {
+		var nonCA Bundle
+		for _, c := range leaves {
+			if !c.inner.IsCA {
+				nonCA = append(nonCA, c)
+			}
+		}
+		if len(nonCA) > 0 {
+			leaves = nonCA
+		}
+	}
+	switch len(leaves) {
+	case 0:
+		return nil, nil, errors.New("no leaf certificate found, certificates issue each other")
+	case 1:
+	default:
+		var names []string
+		for _, c := range leaves {
+			names = append(names, displayName(c.inner.Subject))
+		}
+		return nil, nil, fmt.Errorf("several leaf certificates found: %s", strings.Join(names, ", "))
+	}
+
+	chain = Bundle{leaves[0]}
+	for cur := leaves[0]; !isSelfSigned(cur); {
+		issuer := issuerOf(cur, certs)
+		if issuer == nil || contains(chain, issuer) {
+			break
+		}
+		chain = append(chain, issuer)
+		cur = issuer
+	}
+
+	for _, c := range certs {
+		if !contains(chain, c) {
+			unused = append(unused, c)
+		}
+	}
+	if !withRoot && len(chain) > 1 && isSelfSigned(chain[len(chain)-1]) {
+		chain = chain[:len(chain)-1]
+	}
+	return chain, unused, nil
+}
+
+// dedupe removes repeated certificates keeping the first occurrence.
+func dedupe(certs Bundle) Bundle {
+	seen := make(map[Fingerprint]bool)
+	var unique Bundle
+	for _, c := range certs {
+		if !seen[c.fingerprint] {
+			seen[c.fingerprint] = true
+			unique = append(unique, c)
+		}
+	}
+	return unique
+}
+
+func contains(bundle Bundle, cert *Certificate) bool {
+	for _, c := range bundle {
+		if c.fingerprint == cert.fingerprint {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitBundle writes each certificate to a PEM file in dir named after its
+// common name or fingerprint and returns the paths.
+func SplitBundle(bundle Bundle, dir string, force bool) ([]string, error) {
+	var paths []string
+	used := make(map[string]bool)
+	for _, c := range dedupe(bundle) {
+		name := fileName(c)
+		if used[name] {
+			// Cross-signed and renewed certificates share the common name
+			name += "_" + shortFingerprint(c.fingerprint)
+		}
+		used[name] = true
+
+		path := filepath.Join(dir, name+".crt")
+		if err := writePEMCertificate(path, c, force); err != nil {
+			return paths, fmt.Errorf("write %s: %w", displayName(c.inner.Subject), err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// runSplit implements the split subcommand.
+func runSplit(args []string) int {
+	flags := pflag.NewFlagSet("split", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s split [options] <file or URL>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nWrite each certificate of a bundle to <common name>.crt or, without a\n")
+		fmt.Fprintf(flags.Output(), "common name, to <fingerprint>.crt.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	dirFlag := flags.String("dir", ".", "Directory to write the certificates to.")
+	forceFlag := flags.Bool("force", false, "Overwrite existing files.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	bundle, err := Load(flags.Arg(0))
+	if err != nil {
+		log.Printf("failed to load from %v: %v", flags.Arg(0), err)
+		return ExitLoadFailed
+	}
+	if len(bundle) == 0 {
+		log.Printf("no certificates found in %s", flags.Arg(0))
+		return ExitLoadFailed
+	}
+
+	paths, err := SplitBundle(bundle, *dirFlag, *forceFlag)
+	for _, path := range paths {
+		log.Printf("wrote %s", path)
+	}
+	if err != nil {
+		log.Print(err)
+		return ExitLoadFailed
+	}
+	return ExitOK
+}
+
+// runBundle implements the bundle subcommand.
+func runBundle(args []string) int {
+	flags := pflag.NewFlagSet("bundle", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s bundle [options] <file or URL>...\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nAssemble a chain ordered from the leaf to the root out of certificates in\n")
+		fmt.Fprintf(flags.Output(), "the inputs and write it as PEM.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	rootFlag := flags.Bool("root", false, "Include the self-signed root certificate.")
+	outputFlag := flags.String("output", "", "Path to write the chain to. Defaults to stdout.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	certs, err := LoadMulti(flags.Args())
+	if err != nil {
+		log.Printf("failed to load certificates: %v", err)
+		return ExitLoadFailed
+	}
+
+	chain, unused, err := BuildChain(certs, *rootFlag)
+	if err != nil {
+		log.Printf("failed to build chain: %v", err)
+		return ExitVerifyFailed
+	}
+	for _, c := range unused {
+		log.Printf("skipped %s, not on the chain", displayName(c.inner.Subject))
+	}
+
+	return writeChain(*outputFlag, chain)
+}
+
+// writeChain logs the chain and writes it as PEM to the output path or stdout.
+func writeChain(path string, chain Bundle) int {
+	var names []string
+	report := make(Report, 0, len(chain))
+	for _, c := range chain {
+		names = append(names, displayName(c.inner.Subject))
+		report = append(report, &Record{Cert: c})
+	}
+	log.Printf("chain: %s", strings.Join(names, " -> "))
+
+	out, err := OpenOutput(path, 0o644)
+	if err != nil {
+		log.Print(err)
+		return ExitLoadFailed
+	}
+	if err := (&PEMFormatter{}).Format(out, report); err != nil {
+		out.Abort()
+		log.Printf("formatting: %v", err)
+		return ExitLoadFailed
+	}
+	if err := out.Close(); err != nil {
+		log.Print(err)
+		return ExitLoadFailed
+	}
+	return ExitOK
+}
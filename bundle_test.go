@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509/pkix"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// issueTestCert issues a certificate for a new key, self-signed if issuer is
+// nil.
+func issueTestCert(t *testing.T, opts CertOptions, issuer *Certificate, issuerKey crypto.Signer) (*Certificate, crypto.Signer) {
+	t.Helper()
+	key, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	if opts.Validity == 0 {
+		opts.Validity = time.Hour
+	}
+	tmpl, err := opts.Template(key.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	if issuer == nil {
+		issuerKey = key
+	}
+	cert, err := IssueCertificate(tmpl, key.Public(), issuer, issuerKey)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	return cert, key
+}
+
+func TestBuildChain(t *testing.T) {
+	root, rootKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Root"}, IsCA: true, MaxPathLen: -1}, nil, nil)
+	inter, interKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Intermediate"}, IsCA: true, MaxPathLen: 0}, root, rootKey)
+	leaf, _ := issueTestCert(t, CertOptions{Names: []string{"example.com"}}, inter, interKey)
+	other, _ := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Other"}, IsCA: true, MaxPathLen: -1}, nil, nil)
+
+	names := func(b Bundle) []string {
+		var s []string
+		for _, c := range b {
+			s = append(s, c.inner.Subject.CommonName)
+		}
+		return s
+	}
+
+	tests := []struct {
+		name       string
+		certs      Bundle
+		withRoot   bool
+		wantChain  []string
+		wantUnused []string
+	}{
+		{"ordered", Bundle{leaf, inter, root}, false, []string{"example.com", "Intermediate"}, nil},
+		{"shuffled with root", Bundle{root, leaf, inter}, true, []string{"example.com", "Intermediate", "Root"}, nil},
+		{"duplicates", Bundle{inter, leaf, inter, leaf}, false, []string{"example.com", "Intermediate"}, nil},
+		{"unrelated CA", Bundle{other, inter, leaf, root}, true, []string{"example.com", "Intermediate", "Root"}, []string{"Other"}},
+		{"root only", Bundle{root}, false, []string{"Root"}, nil},
+	}
+	for _, tt := range tests {
+		chain, unused, err := BuildChain(tt.certs, tt.withRoot)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := names(chain); !slices.Equal(got, tt.wantChain) {
+			t.Errorf("%s: chain == %v, want %v", tt.name, got, tt.wantChain)
+		}
+		if got := names(unused); !slices.Equal(got, tt.wantUnused) {
+			t.Errorf("%s: unused == %v, want %v", tt.name, got, tt.wantUnused)
+		}
+	}
+
+	leaf2, _ := issueTestCert(t, CertOptions{Names: []string{"example.net"}}, inter, interKey)
+	if _, _, err := BuildChain(Bundle{leaf, leaf2, inter}, false); err == nil {
+		t.Errorf("several leaves didn't fail")
+	}
+}
+
+func TestSplitBundle(t *testing.T) {
+	bundle, err := Load("testdata/example.com.crt")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	dir := t.TempDir()
+	paths, err := SplitBundle(append(bundle, bundle[0]), dir, false)
+	if err != nil {
+		t.Fatalf("split: %v", err)
+	}
+	if len(paths) != len(bundle) {
+		t.Fatalf("wrote %d files, want %d", len(paths), len(bundle))
+	}
+	if want := filepath.Join(dir, "example.com.crt"); paths[0] != want {
+		t.Errorf("paths[0] == %q, want %q", paths[0], want)
+	}
+
+	for i, path := range paths {
+		got, err := Load(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+		if len(got) != 1 || got[0].fingerprint != bundle[i].fingerprint {
+			t.Errorf("%s doesn't hold certificate %d", path, i)
+		}
+	}
+
+	if _, err := SplitBundle(bundle, dir, false); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("splitting over existing files == %v, want exists error", err)
+	}
+}
@@ -0,0 +1,464 @@
+package main
+
+import (
+	"bufio"
+	"crypto"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Files of a local CA directory.
+const (
+	caCertFile    = "ca.crt"
+	caKeyFile     = "ca.key"
+	caCounterFile = "serial"
+	caIndexFile   = "index.txt"
+	caIssuedDir   = "issued"
+	caLockFile    = "lock"
+)
+
+// caLockTimeout is how long issuing waits for another process issuing from
+// the same CA.
+const caLockTimeout = 10 * time.Second
+
+// LocalCA is a CA for development kept in a directory with its key,
+// certificate, a counter and an index of issued certificates. Serial numbers
+// are random like for other certificates, so a recreated CA with the same
+// subject doesn't reuse them; the counter only numbers issued certificates.
+type LocalCA struct {
+	Dir  string
+	Cert *Certificate
+	Key  crypto.Signer
+}
+
+// IndexEntry is a line of the CA index.
+type IndexEntry struct {
+	Serial   *big.Int
+	NotAfter time.Time
+	Subject  string
+}
+
+// InitCA creates a new CA in dir. It fails if the directory already has a CA.
+func InitCA(dir string, opts CertOptions, keyType string, keySize int) (*LocalCA, error) {
+	if _, err := os.Stat(filepath.Join(dir, caCertFile)); err == nil {
+		return nil, fmt.Errorf("CA already exists in %s", dir)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, caIssuedDir), 0o700); err != nil {
+		return nil, fmt.Errorf("create CA directory: %w", err)
+	}
+
+	key, err := GenerateKey(keyType, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+
+	opts.IsCA = true
+	tmpl, err := opts.Template(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	cert, err := IssueCertificate(tmpl, key.Public(), nil, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeKeyPair(filepath.Join(dir, caCertFile), filepath.Join(dir, caKeyFile), cert, key, false); err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(dir, caCounterFile), []byte("1\n"), 0o600, false); err != nil {
+		return nil, fmt.Errorf("write counter: %w", err)
+	}
+
+	return &LocalCA{Dir: dir, Cert: cert, Key: key}, nil
+}
+
+// OpenCA loads the CA from dir.
+func OpenCA(dir string) (*LocalCA, error) {
+	bundle, err := Load(filepath.Join(dir, caCertFile))
+	if err != nil {
+		return nil, fmt.Errorf("load CA certificate: %w", err)
+	}
+	if len(bundle) == 0 {
+		return nil, errors.New("CA certificate not found")
+	}
+
+	key, err := LoadPrivateKey(filepath.Join(dir, caKeyFile))
+	if err != nil {
+		return nil, fmt.Errorf("load CA key: %w", err)
+	}
+
+	return &LocalCA{Dir: dir, Cert: bundle[0], Key: key}, nil
+}
+
+// Issue signs a certificate for the public key with a random serial number not
+// used by the CA yet, keeps a copy in the CA directory, counts it and records it
+// in the index. save is called
+// with the certificate first, e.g. to write it with its key, and nothing is
+// recorded if it fails. The CA is locked while issuing.
+func (ca *LocalCA) Issue(opts CertOptions, pub crypto.PublicKey, save func(*Certificate) error) (*Certificate, error) {
+	unlock, err := ca.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	tmpl, err := opts.Template(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	count, err := ca.readCounter()
+	if err != nil {
+		return nil, err
+	}
+
+	// Copies of issued certificates are named by serial, a collision is
+	// practically impossible but would fail writing the copy
+	for ca.issued(tmpl.SerialNumber) {
+		tmpl.SerialNumber, err = newSerialNumber()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cert, err := IssueCertificate(tmpl, pub, ca.Cert, ca.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	if save != nil {
+		if err := save(cert); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writePEMCertificate(ca.issuedPath(tmpl.SerialNumber), cert, false); err != nil {
+		return nil, fmt.Errorf("write issued certificate: %w", err)
+	}
+
+	next := new(big.Int).Add(count, big.NewInt(1))
+	if err := writeFile(filepath.Join(ca.Dir, caCounterFile), fmt.Appendf(nil, "%X\n", next), 0o600, true); err != nil {
+		return nil, fmt.Errorf("write counter: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(ca.Dir, caIndexFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	defer f.Close()
+
+	entry := fmt.Sprintf("%X\t%s\t%s\n", tmpl.SerialNumber, cert.inner.NotAfter.UTC().Format(time.RFC3339), cert.inner.Subject)
+	if _, err := f.WriteString(entry); err != nil {
+		return nil, fmt.Errorf("write index: %w", err)
+	}
+
+	return cert, nil
+}
+
+// readCounter returns the number of the next issued certificate.
+func (ca *LocalCA) readCounter() (*big.Int, error) {
+	data, err := os.ReadFile(filepath.Join(ca.Dir, caCounterFile))
+	if err != nil {
+		return nil, fmt.Errorf("read counter: %w", err)
+	}
+
+	count, ok := new(big.Int).SetString(strings.TrimSpace(string(data)), 16)
+	if !ok || count.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid counter %q", strings.TrimSpace(string(data)))
+	}
+	return count, nil
+}
+
+// lock creates the lock file of the CA, waiting up to caLockTimeout for
+// another process to remove it, and returns a function removing it.
+func (ca *LocalCA) lock() (func(), error) {
+	path := filepath.Join(ca.Dir, caLockFile)
+	deadline := time.Now().Add(caLockTimeout)
+	for {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return nil, fmt.Errorf("lock CA: %w", err)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("CA is locked by another process, remove %s if none is running", path)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// issued reports whether the CA has a copy of a certificate with the serial.
+func (ca *LocalCA) issued(serial *big.Int) bool {
+	_, err := os.Stat(ca.issuedPath(serial))
+	return err == nil
+}
+
+func (ca *LocalCA) issuedPath(serial *big.Int) string {
+	return filepath.Join(ca.Dir, caIssuedDir, fmt.Sprintf("%X.crt", serial))
+}
+
+// Index reads entries of issued certificates.
+func (ca *LocalCA) Index() ([]IndexEntry, error) {
+	f, err := os.Open(filepath.Join(ca.Dir, caIndexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	defer f.Close()
+
+	var entries []IndexEntry
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		fields := strings.SplitN(scanner.Text(), "\t", 3)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("index line %d: expected 3 fields", n)
+		}
+
+		serial, ok := new(big.Int).SetString(fields[0], 16)
+		if !ok {
+			return nil, fmt.Errorf("index line %d: invalid serial %q", n, fields[0])
+		}
+		notAfter, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("index line %d: %w", n, err)
+		}
+
+		entries = append(entries, IndexEntry{Serial: serial, NotAfter: notAfter, Subject: fields[2]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read index: %w", err)
+	}
+	return entries, nil
+}
+
+// defaultCADir returns $CERT_CA_DIR or a directory in the user config dir.
+func defaultCADir() string {
+	if dir := os.Getenv("CERT_CA_DIR"); dir != "" {
+		return dir
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "cert", "ca")
+	}
+	return "ca"
+}
+
+// caCommands are subcommands of the ca subcommand.
+var caCommands = map[string]func(dir string, args []string) int{
+	"init":  runCAInit,
+	"issue": runCAIssue,
+	"list":  runCAList,
+}
+
+// runCA implements the ca subcommand.
+func runCA(args []string) int {
+	flags := pflag.NewFlagSet("ca", pflag.ContinueOnError)
+	flags.SetInterspersed(false)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s ca [--dir <dir>] init [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "       %s ca [--dir <dir>] issue [options] <name>...\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "       %s ca [--dir <dir>] list\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nManage a local development CA.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	dir := flags.String("dir", defaultCADir(), "CA directory. Defaults to $CERT_CA_DIR or cert/ca in the user config directory.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return ExitUsage
+	}
+	cmd, ok := caCommands[flags.Arg(0)]
+	if !ok {
+		log.Printf("unknown ca command %q", flags.Arg(0))
+		flags.Usage()
+		return ExitUsage
+	}
+	return cmd(*dir, flags.Args()[1:])
+}
+
+func runCAInit(dir string, args []string) int {
+	flags := pflag.NewFlagSet("ca init", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s ca init [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nCreate a CA key and certificate in the CA directory.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	subjectFlag := flags.String("subject", "CN=cert development CA", "CA subject.")
+	validityFlag := FlagSetDurationP(flags, "validity", "", defaultCAValidity, "Validity period of the CA.")
+	keyTypeFlag := flags.String("key-type", KeyECDSA, "Key type - rsa, ecdsa, ed25519.")
+	keySizeFlag := flags.Int("key-size", 0, "RSA key size or ECDSA curve size in bits.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	subject, err := ParseSubject(*subjectFlag)
+	if err != nil {
+		log.Printf("failed to parse subject: %v", err)
+		return ExitUsage
+	}
+
+	ca, err := InitCA(dir, CertOptions{Subject: subject, Validity: *validityFlag, MaxPathLen: 0}, *keyTypeFlag, *keySizeFlag)
+	if err != nil {
+		log.Printf("failed to create CA: %v", err)
+		return ExitLoadFailed
+	}
+	log.Printf("created CA in %s", dir)
+
+	return printIssued(ca.Cert, nil, CompactOutput)
+}
+
+func runCAIssue(dir string, args []string) int {
+	flags := pflag.NewFlagSet("ca issue", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s ca issue [options] <name>...\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nIssue a certificate for host names, IP addresses or emails.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	validityFlag := FlagSetDurationP(flags, "validity", "", defaultLeafValidity, "Validity period.")
+	keyTypeFlag := flags.String("key-type", KeyECDSA, "Key type - rsa, ecdsa, ed25519.")
+	keySizeFlag := flags.Int("key-size", 0, "RSA key size or ECDSA curve size in bits.")
+	extKeyUsageFlag := flags.StringSlice("ext-key-usage", []string{"server-auth"}, "Extended key usages, e.g. server-auth,client-auth.")
+	certOutFlag := flags.String("cert-out", "", "Path to write the certificate to. Defaults to <first name>.crt.")
+	keyOutFlag := flags.String("key-out", "", "Path to write the private key to. Defaults to <first name>.key.")
+	forceFlag := flags.Bool("force", false, "Overwrite existing files.")
+	verbosityFlag := flags.CountP("verbose", "v", "Increase output verbosity. Can be specified multiple times.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() == 0 {
+		log.Print(errNoNames)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	verbosity, err := NewOutputLevel(*verbosityFlag)
+	if err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	eku, err := ParseExtKeyUsage(*extKeyUsageFlag)
+	if err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	ca, err := OpenCA(dir)
+	if err != nil {
+		log.Printf("failed to open CA in %s: %v (run ca init first)", dir, err)
+		return ExitLoadFailed
+	}
+
+	key, err := GenerateKey(*keyTypeFlag, *keySizeFlag)
+	if err != nil {
+		log.Printf("failed to generate key: %v", err)
+		return ExitUsage
+	}
+
+	// The key pair is written before the CA records the certificate, so
+	// existing files don't leave entries without keys
+	var certOut, keyOut string
+	cert, err := ca.Issue(CertOptions{Names: flags.Args(), Validity: *validityFlag, ExtKeyUsage: eku}, key.Public(), func(cert *Certificate) error {
+		name := fileName(cert)
+		certOut, keyOut = *certOutFlag, *keyOutFlag
+		if certOut == "" {
+			certOut = name + ".crt"
+		}
+		if keyOut == "" {
+			keyOut = name + ".key"
+		}
+		return writeKeyPair(certOut, keyOut, cert, key, *forceFlag)
+	})
+	if err != nil {
+		log.Printf("failed to issue certificate: %v", err)
+		return ExitLoadFailed
+	}
+	log.Printf("wrote %s and %s", certOut, keyOut)
+
+	return printIssued(cert, Bundle{ca.Cert}, verbosity)
+}
+
+func runCAList(dir string, args []string) int {
+	if len(args) > 0 {
+		log.Printf("unexpected arguments: %v", args)
+		return ExitUsage
+	}
+
+	ca, err := OpenCA(dir)
+	if err != nil {
+		log.Printf("failed to open CA in %s: %v", dir, err)
+		return ExitLoadFailed
+	}
+
+	entries, err := ca.Index()
+	if err != nil {
+		log.Printf("failed to read index: %v", err)
+		return ExitLoadFailed
+	}
+
+	if err := (&TextFormatter{}).FormatCAIndex(os.Stdout, ca, entries, time.Now()); err != nil {
+		log.Printf("formatting: %v", err)
+		return ExitLoadFailed
+	}
+	return ExitOK
+}
+
+// FormatCAIndex prints a table of issued certificates, each verified against
+// the CA.
+func (f *TextFormatter) FormatCAIndex(out io.Writer, ca *LocalCA, entries []IndexEntry, now time.Time) error {
+	fmt.Fprintf(out, "CA: %s (%s)\n\n", ca.Cert.inner.Subject, ca.Dir)
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "SERIAL\tSUBJECT\tNOT AFTER\tSTATUS\n")
+	for _, e := range entries {
+		status := printBool(false) + " missing"
+		if bundle, err := Load(ca.issuedPath(e.Serial)); err == nil && len(bundle) > 0 {
+			report, err := Verify(bundle, &VerifyOptions{Time: now, Roots: Bundle{ca.Cert}, KeyUsages: bundle[0].inner.ExtKeyUsage})
+			if err == nil {
+				status = printBool(report.ExitCode() == ExitOK)
+			}
+		}
+		fmt.Fprintf(w, "%X\t%s\t%s\t%s\n", e.Serial, e.Subject, e.NotAfter.Format("2006-01-02"), status)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("tabwriter failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocalCA(t *testing.T) {
+	dir := t.TempDir()
+	opts := CertOptions{Subject: pkix.Name{CommonName: "Test CA"}, Validity: time.Hour}
+	if _, err := InitCA(dir, opts, KeyECDSA, 0); err != nil {
+		t.Fatalf("init: %v", err)
+	}
+	if _, err := InitCA(dir, opts, KeyECDSA, 0); err == nil {
+		t.Errorf("second init didn't fail")
+	}
+
+	ca, err := OpenCA(dir)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if !ca.Cert.inner.IsCA {
+		t.Errorf("CA certificate is not a CA")
+	}
+
+	leaves := []CertOptions{
+		{Names: []string{"example.test", "127.0.0.1"}, Validity: time.Hour},
+		{Names: []string{"user@example.test"}, Validity: time.Hour, ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}},
+	}
+	var serials []*big.Int
+	for _, leafOpts := range leaves {
+		key, err := GenerateKey(KeyECDSA, 0)
+		if err != nil {
+			t.Fatalf("generate key: %v", err)
+		}
+		cert, err := ca.Issue(leafOpts, key.Public(), nil)
+		if err != nil {
+			t.Fatalf("issue: %v", err)
+		}
+		for _, f := range Lint(cert, DefaultLintRules) {
+			if f.RuleID == "serial-entropy" {
+				t.Errorf("%s: %v", leafOpts.Names[0], f)
+			}
+		}
+		serials = append(serials, cert.inner.SerialNumber)
+
+		report, err := Verify(Bundle{cert}, &VerifyOptions{Time: time.Now(), Roots: Bundle{ca.Cert}, KeyUsages: cert.inner.ExtKeyUsage})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if got := report.ExitCode(); got != ExitOK {
+			t.Errorf("%s: ExitCode == %d, want %d", leafOpts.Names[0], got, ExitOK)
+		}
+	}
+
+	entries, err := ca.Index()
+	if err != nil {
+		t.Fatalf("index: %v", err)
+	}
+	if len(entries) != len(leaves) {
+		t.Fatalf("index has %d entries, want %d", len(entries), len(leaves))
+	}
+	for i, e := range entries {
+		if want := "CN=" + leaves[i].Names[0]; e.Subject != want || e.Serial.Cmp(serials[i]) != 0 {
+			t.Errorf("entry %d == %X %q, want %X %q", i, e.Serial, e.Subject, serials[i], want)
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, caCounterFile)); err != nil || string(data) != "3\n" {
+		t.Errorf("counter == %q, %v, want 3", data, err)
+	}
+
+	// Nothing is recorded if saving the certificate fails
+	key, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	errSave := errors.New("exists")
+	if _, err := ca.Issue(leaves[0], key.Public(), func(*Certificate) error { return errSave }); !errors.Is(err, errSave) {
+		t.Errorf("issue with failing save == %v, want %v", err, errSave)
+	}
+	if entries, _ := ca.Index(); len(entries) != len(leaves) {
+		t.Errorf("index has %d entries after failed save, want %d", len(entries), len(leaves))
+	}
+
+	// Concurrent issues get distinct serials
+	issued := make(chan string, 4)
+	var wg sync.WaitGroup
+	for range cap(issued) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cert, err := ca.Issue(leaves[0], key.Public(), nil)
+			if err != nil {
+				t.Errorf("concurrent issue: %v", err)
+				return
+			}
+			issued <- cert.inner.SerialNumber.String()
+		}()
+	}
+	wg.Wait()
+	close(issued)
+	seen := make(map[string]bool)
+	for _, serial := range serials {
+		seen[serial.String()] = true
+	}
+	for serial := range issued {
+		if seen[serial] {
+			t.Errorf("serial %s reused", serial)
+		}
+		seen[serial] = true
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"crypto/x509"
 	"fmt"
@@ -46,3 +47,20 @@ func (c *Certificate) String() string {
 
 // Bundle is an ordered collection of certificates, typically representing a chain.
 type Bundle []*Certificate
+
+// issuerOf returns the certificate that signed cert among the candidates.
+func issuerOf(cert *Certificate, candidates ...Bundle) *Certificate {
+	for _, bundle := range candidates {
+		for _, c := range bundle {
+			if bytes.Equal(cert.inner.RawIssuer, c.inner.RawSubject) && cert.inner.CheckSignatureFrom(c.inner) == nil {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+// isSelfSigned reports whether the certificate is issued and signed by itself.
+func isSelfSigned(cert *Certificate) bool {
+	return issuerOf(cert, Bundle{cert}) == cert
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Limits of AIA fetching for missing issuers.
+const (
+	maxAIAFetches = 5
+	maxAIASize    = 1 << 20
+)
+
+// ChainOptions configure building a chain from a pool.
+type ChainOptions struct {
+	VerifyOptions
+
+	// FetchAIA downloads missing issuers from the CA Issuers URLs of the
+	// certificates.
+	FetchAIA bool
+}
+
+// BuildChainFromPool finds the shortest valid chain from the leaf to a trusted
+// root using pool certificates as intermediates. The chain includes the root.
+func BuildChainFromPool(leaf *Certificate, pool Bundle, opts *ChainOptions) (Bundle, error) {
+	vopts := opts.VerifyOptions
+	vopts.Intermediates = append(dedupe(pool), vopts.Intermediates...)
+	if vopts.KeyUsages == nil {
+		vopts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+
+	for fetches := 0; ; fetches++ {
+		chains, err := verifyChain(Bundle{leaf}, "", &vopts)
+		if err == nil {
+			return shortestChain(chains)
+		}
+		if !opts.FetchAIA || fetches == maxAIAFetches {
+			return nil, err
+		}
+
+		fetched, fetchErr := fetchMissingIssuer(leaf, vopts.Intermediates)
+		if fetchErr != nil {
+			return nil, fmt.Errorf("%w (fetching issuer: %v)", err, fetchErr)
+		}
+		if len(fetched) == 0 {
+			return nil, err
+		}
+		vopts.Intermediates = append(vopts.Intermediates, fetched...)
+	}
+}
+
+func shortestChain(chains [][]*x509.Certificate) (Bundle, error) {
+	var shortest []*x509.Certificate
+	for _, c := range chains {
+		if shortest == nil || len(c) < len(shortest) {
+			shortest = c
+		}
+	}
+
+	bundle := make(Bundle, 0, len(shortest))
+	for _, c := range shortest {
+		cert, err := NewCertificateFromX509(c)
+		if err != nil {
+			return nil, err
+		}
+		bundle = append(bundle, cert)
+	}
+	return bundle, nil
+}
+
+// fetchMissingIssuer follows issuers from the leaf through the pool and
+// downloads the issuer of the last certificate from its CA Issuers URLs.
+func fetchMissingIssuer(leaf *Certificate, pool Bundle) (Bundle, error) {
+	cur := leaf
+	for seen := (Bundle{leaf}); !isSelfSigned(cur); {
+		issuer := issuerOf(cur, pool)
+		if issuer == nil {
+			break
+		}
+		if contains(seen, issuer) {
+			return nil, nil
+		}
+		seen = append(seen, issuer)
+		cur = issuer
+	}
+	if isSelfSigned(cur) {
+		return nil, nil
+	}
+
+	var errs []error
+	for _, url := range cur.inner.IssuingCertificateURL {
+		certs, err := fetchCertificates(url)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var issuers Bundle
+		for _, c := range certs {
+			if issuerOf(cur, Bundle{c}) != nil && !contains(pool, c) {
+				issuers = append(issuers, c)
+			}
+		}
+		if len(issuers) > 0 {
+			log.Printf("fetched issuer of %s from %s", displayName(cur.inner.Subject), url)
+			return issuers, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
+
+// fetchCertificates downloads certificates in DER, PEM or PKCS #7 format.
+func fetchCertificates(url string) (Bundle, error) {
+	client := &http.Client{Timeout: Timeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch %s: %s", url, resp.Status)
+	}
+
+	in, err := fromReader(io.LimitReader(resp.Body, maxAIASize))
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", url, err)
+	}
+	return in.Bundle, nil
+}
+
+// runChain implements the chain subcommand.
+func runChain(args []string) int {
+	flags := pflag.NewFlagSet("chain", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s chain --pool <dir> [options] <leaf>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nBuild the shortest chain from the leaf to a trusted root out of a pool of CA\n")
+		fmt.Fprintf(flags.Output(), "certificates and write it as PEM, ready for web servers like nginx.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	poolFlag := flags.StringSlice("pool", nil, "CA certificates to build the chain from. Can be a single certificate, a bundle, a directory or a glob. Can be specified multiple times.")
+	rootsFlag := flags.StringSliceP("roots", "r", nil, "Path to custom roots trusted in addition to the system ones. Can be specified multiple times.")
+	fetchFlag := flags.Bool("fetch", false, "Download missing issuers from the CA Issuers URLs of the certificates.")
+	rootFlag := flags.Bool("root", false, "Include the root certificate.")
+	outputFlag := flags.String("output", "", "Path to write the chain to. Defaults to stdout.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	bundle, err := Load(flags.Arg(0))
+	if err != nil || len(bundle) == 0 {
+		log.Printf("failed to load leaf from %v: %v", flags.Arg(0), err)
+		return ExitLoadFailed
+	}
+
+	pool, err := LoadMulti(*poolFlag)
+	if err != nil {
+		log.Printf("failed to load pool: %v", err)
+		return ExitLoadFailed
+	}
+	roots, err := LoadMulti(*rootsFlag)
+	if err != nil {
+		log.Printf("failed to load roots: %v", err)
+		return ExitLoadFailed
+	}
+
+	// Certificates following the leaf in its file join the pool
+	chain, err := BuildChainFromPool(bundle[0], append(pool, bundle[1:]...), &ChainOptions{
+		VerifyOptions: VerifyOptions{Time: time.Now(), Roots: roots},
+		FetchAIA:      *fetchFlag,
+	})
+	if err != nil {
+		log.Printf("failed to build chain: %v", err)
+		return ExitVerifyFailed
+	}
+	if !*rootFlag && len(chain) > 1 {
+		chain = chain[:len(chain)-1]
+	}
+
+	return writeChain(*outputFlag, chain)
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBuildChainFromPool(t *testing.T) {
+	root, rootKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Root"}, IsCA: true, MaxPathLen: -1}, nil, nil)
+	other, otherKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Other"}, IsCA: true, MaxPathLen: -1}, root, rootKey)
+	inter, interKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Intermediate"}, IsCA: true, MaxPathLen: -1}, root, rootKey)
+	leaf, _ := issueTestCert(t, CertOptions{Names: []string{"example.com"}}, inter, interKey)
+
+	// The intermediate cross-signed by another intermediate gives a longer path
+	opts := CertOptions{Subject: pkix.Name{CommonName: "Intermediate"}, Validity: time.Hour, IsCA: true, MaxPathLen: -1}
+	tmpl, err := opts.Template(interKey.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	cross, err := IssueCertificate(tmpl, interKey.Public(), other, otherKey)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	names := func(b Bundle) []string {
+		var s []string
+		for _, c := range b {
+			s = append(s, c.inner.Subject.CommonName+"/"+c.inner.Issuer.CommonName)
+		}
+		return s
+	}
+
+	chainOpts := &ChainOptions{VerifyOptions: VerifyOptions{Time: time.Now(), Roots: Bundle{root}}}
+	chain, err := BuildChainFromPool(leaf, Bundle{cross, other, inter, other}, chainOpts)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	want := []string{"example.com/Intermediate", "Intermediate/Root", "Root/Root"}
+	if got := names(chain); !slices.Equal(got, want) {
+		t.Errorf("chain == %v, want %v", got, want)
+	}
+
+	if _, err := BuildChainFromPool(leaf, Bundle{other}, chainOpts); err == nil {
+		t.Errorf("missing intermediate didn't fail")
+	}
+}
+
+func TestBuildChainFromPoolAIA(t *testing.T) {
+	root, rootKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Root"}, IsCA: true, MaxPathLen: -1}, nil, nil)
+	inter, interKey := issueTestCert(t, CertOptions{Subject: pkix.Name{CommonName: "Intermediate"}, IsCA: true, MaxPathLen: 0}, root, rootKey)
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write(inter.inner.Raw)
+	}))
+	defer srv.Close()
+
+	key, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	opts := CertOptions{Names: []string{"example.com"}, Validity: time.Hour}
+	tmpl, err := opts.Template(key.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	tmpl.IssuingCertificateURL = []string{srv.URL + "/intermediate.crt"}
+	leaf, err := IssueCertificate(tmpl, key.Public(), inter, interKey)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	chainOpts := &ChainOptions{VerifyOptions: VerifyOptions{Time: time.Now(), Roots: Bundle{root}}}
+	if _, err := BuildChainFromPool(leaf, nil, chainOpts); err == nil {
+		t.Errorf("missing intermediate didn't fail without fetching")
+	}
+
+	chainOpts.FetchAIA = true
+	chain, err := BuildChainFromPool(leaf, nil, chainOpts)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if len(chain) != 3 || chain[1].fingerprint != inter.fingerprint {
+		t.Errorf("chain doesn't go through the fetched intermediate: %v", chain)
+	}
+	if requests != 1 {
+		t.Errorf("fetched %d times, want 1", requests)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Nagios plugin states, also used as exit codes in check mode.
+const (
+	CheckOK       = 0
+	CheckWarning  = 1
+	CheckCritical = 2
+	CheckUnknown  = 3
+)
+
+var checkStateNames = [...]string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// Default thresholds for check mode if none are given.
+const (
+	defaultCheckWarn = 30 * 24 * time.Hour
+	defaultCheckCrit = 7 * 24 * time.Hour
+)
+
+// CheckResult is the outcome of a Nagios-compatible check.
+type CheckResult struct {
+	State   int
+	Message string
+
+	// Days until the soonest expiry, reported as performance data
+	Days    int
+	HasDays bool
+
+	Warn time.Duration
+	Crit time.Duration
+}
+
+// NewCheckResult computes the check state from reports of all sections. Load
+// failures are unknown, verification failures and certificates expiring
+// within crit are critical, and those expiring within warn are warnings.
+func NewCheckResult(sections []Section, warn, crit time.Duration) *CheckResult {
+	res := &CheckResult{State: CheckOK, Warn: warn, Crit: crit}
+
+	var soonest *Record
+	for _, s := range sections {
+		if s.Error != nil {
+			res.raise(CheckUnknown, fmt.Sprintf("%s: %v", s.Source, s.Error))
+			continue
+		}
+
+		for _, rec := range s.Report {
+			if rec.Error != nil {
+				res.raise(CheckCritical, fmt.Sprintf("%s: %v", recordName(rec), rec.Error))
+			}
+		}
+
+		if rec := s.Report.Soonest(); rec != nil && (soonest == nil || rec.Validity.ExpiresIn < soonest.Validity.ExpiresIn) {
+			soonest = rec
+		}
+	}
+
+	if soonest == nil {
+		if res.Message == "" {
+			res.raise(CheckUnknown, "no certificates found")
+		}
+		return res
+	}
+
+	expiresIn := time.Duration(soonest.Validity.ExpiresIn)
+	res.Days = int(math.Floor(expiresIn.Hours() / 24))
+	res.HasDays = true
+
+	state := CheckOK
+	switch {
+	case expiresIn < crit:
+		state = CheckCritical
+	case expiresIn < warn:
+		state = CheckWarning
+	}
+
+	name := recordName(soonest)
+	if expiresIn < 0 {
+		res.raise(state, fmt.Sprintf("%s expired %d days ago", name, -res.Days))
+	} else {
+		res.raise(state, fmt.Sprintf("%s expires in %d days", name, res.Days))
+	}
+
+	return res
+}
+
+// raise sets the state and message if the state is worse than the current
+// one. The first message of the worst state wins.
+func (c *CheckResult) raise(state int, message string) {
+	if c.Message == "" || checkSeverity(state) > checkSeverity(c.State) {
+		c.State = state
+		c.Message = message
+	}
+}
+
+// checkSeverity orders states so that unknown ranks between warning and
+// critical, like most Nagios plugins do when aggregating.
+func checkSeverity(state int) int {
+	switch state {
+	case CheckWarning:
+		return 1
+	case CheckUnknown:
+		return 2
+	case CheckCritical:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// String formats the result as a Nagios plugin status line.
+func (c *CheckResult) String() string {
+	s := fmt.Sprintf("CERT %s - %s", checkStateNames[c.State], c.Message)
+	if c.HasDays {
+		s += fmt.Sprintf(" | days=%d;%d;%d", c.Days, int(c.Warn.Hours()/24), int(c.Crit.Hours()/24))
+	}
+	return s
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCheckResult(t *testing.T) {
+	bundle, err := Load(filepath.Join("testdata", "example.com.crt"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	testCases := []struct {
+		time  time.Time
+		state int
+		want  string
+	}{
+		{time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC), CheckOK, "CERT OK - example.com expires in 87 days | days=87;30;7"},
+		{time.Date(2026, 4, 20, 12, 0, 0, 0, time.UTC), CheckWarning, "CERT WARNING - example.com expires in 24 days | days=24;30;7"},
+		{time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC), CheckCritical, "CERT CRITICAL - example.com expires in 4 days | days=4;30;7"},
+	}
+
+	for _, c := range testCases {
+		report, err := Verify(bundle, &VerifyOptions{Time: c.time})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+
+		res := NewCheckResult([]Section{{Source: "example.com", Report: report}}, defaultCheckWarn, defaultCheckCrit)
+		if res.State != c.state {
+			t.Errorf("state at %v == %d, want %d", c.time, res.State, c.state)
+		}
+		if got := res.String(); got != c.want {
+			t.Errorf("status line at %v == %q, want %q", c.time, got, c.want)
+		}
+	}
+
+	res := NewCheckResult([]Section{{Source: "example.com", Error: errors.New("timeout")}}, defaultCheckWarn, defaultCheckCrit)
+	if want := "CERT UNKNOWN - example.com: timeout"; res.State != CheckUnknown || res.String() != want {
+		t.Errorf("status line == %q (%d), want %q", res.String(), res.State, want)
+	}
+}
+
+func TestCheckRequested(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"--bogus", "--check", "example.com"}, true},
+		{[]string{"--check=true"}, true},
+		{[]string{"example.com"}, false},
+		{[]string{"--", "--check"}, false},
+	}
+	for _, tt := range tests {
+		os.Args = append([]string{"cert"}, tt.args...)
+		if got := checkRequested(); got != tt.want {
+			t.Errorf("checkRequested with %v == %t, want %t", tt.args, got, tt.want)
+		}
+	}
+}
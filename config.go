@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"slices"
+	"strconv"
 	"strings"
 
 	"time"
@@ -11,12 +12,29 @@ import (
 )
 
 type Config struct {
-	Source           string
+	Sources          []string
+	TargetsPath      string
+	Parallel         int
+	Timeout          time.Duration
 	Format           Format
+	Template         string
+	Fields           []string
+	ListFields       bool
 	Time             time.Time
 	Verbosity        OutputLevel
 	RootsPath        []string
 	IntermediatePath []string
+	CRLIssuerPath    []string
+	WarnWithin       time.Duration
+	CritWithin       time.Duration
+	Check            bool
+	Watch            time.Duration
+	Quiet            bool
+	Output           string
+	Lint             bool
+	CryptoPolicy     CryptoPolicy
+	CT               bool
+	CTLogsPath       string
 }
 
 type OutputLevel int
@@ -40,9 +58,15 @@ type Format string
 const (
 	FormatText Format = "text"
 	FormatPEM  Format = "pem"
+	FormatTSV  Format = "tsv"
+	FormatCSV  Format = "csv"
+
+	// FormatTemplate is set by --template and --template-file, it can't be
+	// selected with --format
+	FormatTemplate Format = "template"
 )
 
-var validFormats = []Format{FormatText, FormatPEM}
+var validFormats = []Format{FormatText, FormatPEM, FormatTSV, FormatCSV}
 
 // ParseFormat validates input and converts to a Format
 func ParseFormat(s string) (Format, error) {
@@ -78,6 +102,70 @@ func (f *FormatValue) Type() string {
 	return "format"
 }
 
+// ParseDuration parses durations with day ("d") and week ("w") units in
+// addition to the units of time.ParseDuration, e.g. "30d", "2w" or "1.5d".
+func ParseDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+	}
+
+	for suffix, unit := range units {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.ParseFloat(n, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(v * float64(unit)), nil
+		}
+	}
+
+	return time.ParseDuration(s)
+}
+
+// DurationValue implements pflag.Value
+type DurationValue struct {
+	Value *time.Duration
+}
+
+func (d *DurationValue) String() string {
+	if d.Value == nil {
+		return ""
+	}
+	// Let pflag recognize zero value and omit it from defaults
+	if *d.Value == 0 {
+		return "0"
+	}
+	return d.Value.String()
+}
+
+func (d *DurationValue) Set(s string) error {
+	v, err := ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d.Value = v
+	return nil
+}
+
+func (d *DurationValue) Type() string {
+	return "duration"
+}
+
+// DurationP creates a new duration flag accepting days and weeks and returns
+// its value
+func DurationP(name, shorthand string, value time.Duration, usage string) *time.Duration {
+	return FlagSetDurationP(pflag.CommandLine, name, shorthand, value, usage)
+}
+
+// FlagSetDurationP is like DurationP for subcommand flag sets
+func FlagSetDurationP(flags *pflag.FlagSet, name, shorthand string, value time.Duration, usage string) *time.Duration {
+	p := new(time.Duration)
+	*p = value
+	flags.VarP(&DurationValue{Value: p}, name, shorthand, usage)
+	return p
+}
+
 // FormatP creates a new Format flag and returns its value
 func FormatP(name, shorthand string, value Format, usage string) *Format {
 	p := new(Format)
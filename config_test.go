@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"30d", 30 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"1.5d", 36 * time.Hour, false},
+		{"12h", 12 * time.Hour, false},
+		{"0", 0, false},
+		{"xd", 0, true},
+		{"month", 0, true},
+	}
+
+	for _, c := range testCases {
+		got, err := ParseDuration(c.input)
+		if got != c.want {
+			t.Errorf("ParseDuration(%v) == %v, want %v", c.input, got, c.want)
+		}
+
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParseDuration(%v) error == %v, want error %t", c.input, err, c.wantErr)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/pflag"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Conversion target formats.
+const (
+	ConvertPEM = "pem"
+	ConvertDER = "der"
+	ConvertP7B = "p7b"
+	ConvertP12 = "p12"
+)
+
+// DERFormatter encodes a single certificate, request or CRL as DER.
+type DERFormatter struct{}
+
+func (f *DERFormatter) Format(w io.Writer, report Report) error {
+	if len(report) != 1 {
+		return fmt.Errorf("DER holds a single object, got %d (use %s or %s for bundles)", len(report), ConvertPEM, ConvertP7B)
+	}
+	_, err := w.Write(recordBlock(report[0]).Bytes)
+	return err
+}
+
+// PKCS7Formatter encodes certificates and CRLs as a PKCS #7 bundle (P7B).
+type PKCS7Formatter struct{}
+
+func (f *PKCS7Formatter) Format(w io.Writer, report Report) error {
+	var (
+		bundle Bundle
+		crls   []*RevocationList
+	)
+	for _, rec := range report {
+		switch {
+		case rec.Request != nil:
+			return errors.New("PKCS #7 can't hold certificate requests")
+		case rec.CRL != nil:
+			crls = append(crls, rec.CRL)
+		default:
+			bundle = append(bundle, rec.Cert)
+		}
+	}
+	data, err := EncodePKCS7(bundle, crls)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// PKCS12Formatter encodes certificates as a password-protected PKCS #12 file.
+// With a key, the certificate matching it is stored with the key and the rest
+// as its chain. Without a key, it's a trust store.
+type PKCS12Formatter struct {
+	Key      crypto.Signer
+	Password string
+}
+
+func (f *PKCS12Formatter) Format(w io.Writer, report Report) error {
+	data, err := f.encode(report)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func (f *PKCS12Formatter) encode(report Report) ([]byte, error) {
+	var certs []*x509.Certificate
+	for _, rec := range report {
+		if rec.Cert == nil {
+			return nil, errors.New("PKCS #12 can only hold certificates")
+		}
+		certs = append(certs, rec.Cert.inner)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificates to encode")
+	}
+
+	if f.Key == nil {
+		return pkcs12.Modern.EncodeTrustStore(certs, f.Password)
+	}
+
+	pub, ok := f.Key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", f.Key)
+	}
+	for i, cert := range certs {
+		if pub.Equal(cert.PublicKey) {
+			chain := append(certs[:i:i], certs[i+1:]...)
+			return pkcs12.Modern.Encode(f.Key, cert, chain, f.Password)
+		}
+	}
+	return nil, errors.New("private key doesn't match any certificate")
+}
+
+// newConvertFormatter returns a formatter for the conversion target format.
+func newConvertFormatter(format string, key crypto.Signer, password string) (Formatter, error) {
+	if key != nil && format != ConvertP12 {
+		return nil, fmt.Errorf("a private key can only be included in %s", ConvertP12)
+	}
+
+	switch format {
+	case ConvertPEM:
+		return &PEMFormatter{}, nil
+	case ConvertDER:
+		return &DERFormatter{}, nil
+	case ConvertP7B:
+		return &PKCS7Formatter{}, nil
+	case ConvertP12:
+		return &PKCS12Formatter{Key: key, Password: password}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q (valid: %s)", format, strings.Join([]string{ConvertPEM, ConvertDER, ConvertP7B, ConvertP12}, ", "))
+	}
+}
+
+// runConvert implements the convert subcommand.
+func runConvert(args []string) int {
+	flags := pflag.NewFlagSet("convert", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s convert --to pem|der|p7b|p12 [options] <file>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nConvert certificates, requests and CRLs between PEM, DER, PKCS #7 and PKCS #12.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+
+	toFlag := flags.String("to", "", "Output format - pem, der, p7b, p12.")
+	outFlag := flags.StringP("out", "o", "", "Path to write the output to. Defaults to stdout.")
+	keyFlag := flags.String("key", "", "Path to a private key to include in the PKCS #12 file.")
+	passwordFlag := flags.String("password", "", "Password of the PKCS #12 file.")
+	forceFlag := flags.Bool("force", false, "Overwrite the existing output file.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() != 1 || *toFlag == "" {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	var key crypto.Signer
+	if *keyFlag != "" {
+		var err error
+		if key, err = LoadPrivateKey(*keyFlag); err != nil {
+			log.Printf("failed to load key: %v", err)
+			return ExitLoadFailed
+		}
+	}
+
+	formatter, err := newConvertFormatter(*toFlag, key, *passwordFlag)
+	if err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	in, err := LoadInput(flags.Arg(0))
+	if err != nil {
+		log.Printf("failed to load %s: %v", flags.Arg(0), err)
+		return ExitLoadFailed
+	}
+
+	var report Report
+	for _, cert := range in.Bundle {
+		report = append(report, &Record{Cert: cert})
+	}
+	for _, req := range in.Requests {
+		report = append(report, &Record{Request: req})
+	}
+	for _, crl := range in.CRLs {
+		report = append(report, &Record{CRL: crl})
+	}
+
+	if *outFlag == "" && *toFlag != ConvertPEM && isTerminal(os.Stdout) {
+		log.Printf("refusing to write binary %s to the terminal, use --out", *toFlag)
+		return ExitUsage
+	}
+	if *outFlag != "" && !*forceFlag {
+		if _, err := os.Stat(*outFlag); err == nil {
+			log.Printf("failed to write output: %s already exists, use --force to overwrite", *outFlag)
+			return ExitLoadFailed
+		}
+	}
+
+	perm := os.FileMode(0o644)
+	if key != nil {
+		perm = 0o600
+	}
+	out, err := OpenOutput(*outFlag, perm)
+	if err != nil {
+		log.Print(err)
+		return ExitLoadFailed
+	}
+	if err := formatter.Format(out, report); err != nil {
+		out.Abort()
+		log.Printf("failed to convert: %v", err)
+		return ExitVerifyFailed
+	}
+	if err := out.Close(); err != nil {
+		log.Print(err)
+		return ExitLoadFailed
+	}
+	return ExitOK
+}
+
+// isTerminal reports whether the file is a character device such as a
+// terminal.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509/pkix"
+	"io"
+	"testing"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func TestPKCS7RoundTrip(t *testing.T) {
+	var (
+		report Report
+		certs  Bundle
+		crls   []*RevocationList
+	)
+	for _, path := range []string{"testdata/example.com.crt", "testdata/ca.crt", "testdata/ca.crl"} {
+		in, err := LoadInput(path)
+		if err != nil {
+			t.Fatalf("load %s: %v", path, err)
+		}
+		for _, cert := range in.Bundle {
+			report = append(report, &Record{Cert: cert})
+		}
+		for _, crl := range in.CRLs {
+			report = append(report, &Record{CRL: crl})
+		}
+		certs = append(certs, in.Bundle...)
+		crls = append(crls, in.CRLs...)
+	}
+
+	var b bytes.Buffer
+	if err := (&PKCS7Formatter{}).Format(&b, report); err != nil {
+		t.Fatalf("format: %v", err)
+	}
+	in, err := fromDER(b.Bytes())
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+
+	if len(in.Bundle) != len(certs) || len(in.CRLs) != len(crls) {
+		t.Fatalf("got %d certificates and %d CRLs, want %d and %d", len(in.Bundle), len(in.CRLs), len(certs), len(crls))
+	}
+	for i, cert := range in.Bundle {
+		if !bytes.Equal(cert.Bytes(), certs[i].Bytes()) {
+			t.Errorf("certificate %d differs", i)
+		}
+	}
+	for i, crl := range in.CRLs {
+		if !bytes.Equal(crl.Bytes(), crls[i].Bytes()) {
+			t.Errorf("CRL %d differs", i)
+		}
+	}
+
+	b.Reset()
+	if err := (&DERFormatter{}).Format(&b, report); err == nil {
+		t.Errorf("DER encoding of several objects didn't fail")
+	}
+	if err := (&DERFormatter{}).Format(&b, report[:1]); err != nil || !bytes.Equal(b.Bytes(), report[0].Cert.Bytes()) {
+		t.Errorf("DER encoding == %v, want the certificate", err)
+	}
+}
+
+func TestPKCS12Formatter(t *testing.T) {
+	caKey, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	caOpts := CertOptions{Subject: pkix.Name{CommonName: "Test CA"}, Validity: time.Hour, IsCA: true, MaxPathLen: -1}
+	caTmpl, err := caOpts.Template(caKey.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	ca, err := IssueCertificate(caTmpl, caKey.Public(), nil, caKey)
+	if err != nil {
+		t.Fatalf("issue CA: %v", err)
+	}
+
+	key, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	leafOpts := CertOptions{Names: []string{"example.com"}, Validity: time.Hour}
+	tmpl, err := leafOpts.Template(key.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	leaf, err := IssueCertificate(tmpl, key.Public(), ca, caKey)
+	if err != nil {
+		t.Fatalf("issue leaf: %v", err)
+	}
+
+	// The key matches the second certificate, the rest is its chain
+	report := Report{{Cert: ca}, {Cert: leaf}}
+	var b bytes.Buffer
+	if err := (&PKCS12Formatter{Key: key, Password: "secret"}).Format(&b, report); err != nil {
+		t.Fatalf("format: %v", err)
+	}
+	gotKey, gotCert, gotChain, err := pkcs12.DecodeChain(b.Bytes(), "secret")
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if signer, ok := gotKey.(crypto.Signer); !ok || !key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(signer.Public()) {
+		t.Errorf("decoded key differs")
+	}
+	if !bytes.Equal(gotCert.Raw, leaf.Bytes()) || len(gotChain) != 1 || !bytes.Equal(gotChain[0].Raw, ca.Bytes()) {
+		t.Errorf("decoded certificate or chain differs")
+	}
+
+	if err := (&PKCS12Formatter{Key: caKey}).Format(io.Discard, Report{{Cert: leaf}}); err == nil {
+		t.Errorf("encoding with a mismatched key didn't fail")
+	}
+
+	b.Reset()
+	if err := (&PKCS12Formatter{Password: "secret"}).Format(&b, report); err != nil {
+		t.Fatalf("format trust store: %v", err)
+	}
+	if certs, err := pkcs12.DecodeTrustStore(b.Bytes(), "secret"); err != nil || len(certs) != 2 {
+		t.Errorf("DecodeTrustStore == %d certificates, %v", len(certs), err)
+	}
+}
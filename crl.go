@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"fmt"
+)
+
+// RevocationList wraps an x509.RevocationList (CRL).
+type RevocationList struct {
+	inner *x509.RevocationList
+}
+
+// NewRevocationList creates a revocation list from DER-encoded data block
+func NewRevocationList(data []byte) (*RevocationList, error) {
+	inner, err := x509.ParseRevocationList(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse CRL: %w", err)
+	}
+	return &RevocationList{inner}, nil
+}
+
+// Bytes returns the DER-encoded form of the revocation list.
+func (l *RevocationList) Bytes() []byte {
+	return l.inner.Raw
+}
+
+func (l *RevocationList) String() string {
+	return fmt.Sprintf("RevocationList{Issuer: %s, ThisUpdate: %s, NextUpdate: %s, Number: %v, Revoked: %d}",
+		l.inner.Issuer.String(),
+		l.inner.ThisUpdate.Format("2006-01-02 15:04:05"),
+		l.inner.NextUpdate.Format("2006-01-02 15:04:05"),
+		l.inner.Number,
+		len(l.inner.RevokedCertificateEntries))
+}
+
+// findIssuer returns the certificate from candidates that signed the CRL. It
+// returns nil and no error if there is no candidate with a matching subject.
+func (l *RevocationList) findIssuer(candidates ...Bundle) (*Certificate, error) {
+	var lastErr error
+	for _, bundle := range candidates {
+		for _, c := range bundle {
+			if !bytes.Equal(c.inner.RawSubject, l.inner.RawIssuer) {
+				continue
+			}
+
+			lastErr = l.inner.CheckSignatureFrom(c.inner)
+			if lastErr == nil {
+				return c, nil
+			}
+		}
+	}
+	return nil, lastErr
+}
+
+// revocationReasons names CRL reason codes from RFC 5280, section 5.3.1.
+var revocationReasons = map[int]string{
+	0:  "Unspecified",
+	1:  "Key Compromise",
+	2:  "CA Compromise",
+	3:  "Affiliation Changed",
+	4:  "Superseded",
+	5:  "Cessation Of Operation",
+	6:  "Certificate Hold",
+	8:  "Remove From CRL",
+	9:  "Privilege Withdrawn",
+	10: "AA Compromise",
+}
+
+func revocationReason(code int) string {
+	if name, ok := revocationReasons[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown(%d)", code)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// CreateRequest creates a certificate request signed by the key. The common
+// name defaults to the first name.
+func CreateRequest(subject pkix.Name, names []string, key crypto.Signer) (*Request, error) {
+	sans, err := parseNames(names)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := &x509.CertificateRequest{
+		Subject:        subject,
+		DNSNames:       sans.DNSNames,
+		IPAddresses:    sans.IPAddresses,
+		EmailAddresses: sans.EmailAddresses,
+		URIs:           sans.URIs,
+	}
+	if tmpl.Subject.CommonName == "" && len(names) > 0 {
+		tmpl.Subject.CommonName = names[0]
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	if err != nil {
+		return nil, fmt.Errorf("create certificate request: %w", err)
+	}
+	return NewRequest(der)
+}
+
+// runCSR implements the csr subcommand.
+func runCSR(args []string) int {
+	flags := pflag.NewFlagSet("csr", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s csr [options] [name...]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "       %s csr --from <cert.pem> [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nCreate a certificate signing request for the names or, for renewals, with\n")
+		fmt.Fprintf(flags.Output(), "the subject and SANs of an existing certificate.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+
+	fromFlag := flags.String("from", "", "Path to a certificate to copy the subject and SANs from.")
+	subjectFlag := flags.String("subject", "", `Subject, e.g. "CN=example.com,O=Example,C=US". The common name defaults to the first name.`)
+	keyFlag := flags.String("key", "", "Path to the private key. A new key is generated if not set.")
+	keyTypeFlag := flags.String("key-type", KeyECDSA, "Type of the generated key - rsa, ecdsa, ed25519.")
+	keySizeFlag := flags.Int("key-size", 0, "RSA key size or ECDSA curve size in bits of the generated key.")
+	outFlag := flags.String("out", "", "Path to write the request to. Defaults to <common name>.csr.")
+	keyOutFlag := flags.String("key-out", "", "Path to write the generated key to. Defaults to <common name>.key.")
+	forceFlag := flags.Bool("force", false, "Overwrite existing files.")
+	verbosityFlag := flags.CountP("verbose", "v", "Increase output verbosity. Can be specified multiple times.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	verbosity, err := NewOutputLevel(*verbosityFlag)
+	if err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	var (
+		subject pkix.Name
+		names   []string
+	)
+	if *fromFlag != "" {
+		bundle, err := Load(*fromFlag)
+		if err != nil || len(bundle) == 0 {
+			log.Printf("failed to load certificate: %v", err)
+			return ExitLoadFailed
+		}
+		subject = bundle[0].inner.Subject
+		names = certSANs(bundle[0].inner).names()
+	}
+	if *subjectFlag != "" {
+		if subject, err = ParseSubject(*subjectFlag); err != nil {
+			log.Printf("failed to parse subject: %v", err)
+			return ExitUsage
+		}
+	}
+	if flags.NArg() > 0 {
+		names = flags.Args()
+	}
+	if subject.CommonName == "" && len(names) == 0 {
+		log.Print(errNoNames)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	var key crypto.Signer
+	if *keyFlag != "" {
+		if key, err = LoadPrivateKey(*keyFlag); err != nil {
+			log.Printf("failed to load key: %v", err)
+			return ExitLoadFailed
+		}
+	} else if key, err = GenerateKey(*keyTypeFlag, *keySizeFlag); err != nil {
+		log.Printf("failed to generate key: %v", err)
+		return ExitUsage
+	}
+
+	req, err := CreateRequest(subject, names, key)
+	if err != nil {
+		log.Printf("failed to create request: %v", err)
+		return ExitUsage
+	}
+
+	name := safeFileName(req.inner.Subject.CommonName)
+	out, keyOut := *outFlag, *keyOutFlag
+	if out == "" {
+		out = name + ".csr"
+	}
+	var files []outputFile
+	if *keyFlag == "" {
+		if keyOut == "" {
+			keyOut = name + ".key"
+		}
+		keyPEM, err := EncodeKeyPEM(key)
+		if err != nil {
+			log.Print(err)
+			return ExitUsage
+		}
+		files = append(files, outputFile{keyOut, keyPEM, 0o600})
+	}
+	reqPEM := pem.EncodeToMemory(&pem.Block{Type: PEMRequestType, Bytes: req.Bytes()})
+	files = append(files, outputFile{out, reqPEM, 0o644})
+
+	if err := writeFiles(*forceFlag, files...); err != nil {
+		log.Printf("failed to write request: %v", err)
+		return ExitLoadFailed
+	}
+	if *keyFlag == "" {
+		log.Printf("wrote %s and %s", out, keyOut)
+	} else {
+		log.Printf("wrote %s", out)
+	}
+
+	report := Report{NewRequestRecord(req, &VerifyOptions{Time: time.Now(), CryptoPolicy: &DefaultCryptoPolicy})}
+	if err := (&TextFormatter{Verbosity: verbosity}).Format(os.Stdout, report); err != nil {
+		log.Printf("formatting: %v", err)
+		return ExitVerifyFailed
+	}
+	return report.ExitCode()
+}
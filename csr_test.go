@@ -0,0 +1,49 @@
+package main
+
+import (
+	"crypto/x509/pkix"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCreateRequest(t *testing.T) {
+	key, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+
+	certOpts := CertOptions{Subject: pkix.Name{Organization: []string{"Example"}}, Names: []string{"example.com", "10.0.0.1", "admin@example.com"}, Validity: time.Hour}
+	tmpl, err := certOpts.Template(key.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	cert, err := IssueCertificate(tmpl, key.Public(), nil, key)
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+
+	// Renewal copies the subject and SANs with a new key
+	newKey, err := GenerateKey(KeyRSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	req, err := CreateRequest(cert.inner.Subject, certSANs(cert.inner).names(), newKey)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+
+	r := req.inner
+	if err := r.CheckSignature(); err != nil {
+		t.Errorf("CheckSignature: %v", err)
+	}
+	if got, want := r.Subject.String(), "CN=example.com,O=Example"; got != want {
+		t.Errorf("subject == %q, want %q", got, want)
+	}
+	if got, want := requestSANs(r).names(), certOpts.Names; !slices.Equal(got, want) {
+		t.Errorf("SANs == %v, want %v", got, want)
+	}
+	if rec := NewRequestRecord(req, &VerifyOptions{CryptoPolicy: &DefaultCryptoPolicy}); rec.Error != nil || rec.KeyWarning != "" {
+		t.Errorf("request record has error %v, key warning %q", rec.Error, rec.KeyWarning)
+	}
+}
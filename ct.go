@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+)
+
+// minCTOperators is how many distinct log operators must have issued valid
+// SCTs for a certificate to be considered CT compliant.
+const minCTOperators = 2
+
+// CTLog is a Certificate Transparency log that SCTs are verified against.
+type CTLog struct {
+	Description string
+	Operator    string
+	Key         crypto.PublicKey
+}
+
+// CTLogList maps log IDs, SHA-256 hashes of log keys, to logs.
+type CTLogList map[[32]byte]*CTLog
+
+// ctLogListJSON is the subset of the v3 log list schema needed to verify SCTs.
+type ctLogListJSON struct {
+	Operators []struct {
+		Name      string      `json:"name"`
+		Logs      []ctLogJSON `json:"logs"`
+		TiledLogs []ctLogJSON `json:"tiled_logs"`
+	} `json:"operators"`
+}
+
+type ctLogJSON struct {
+	Description string `json:"description"`
+	Key         []byte `json:"key"`
+}
+
+// ParseCTLogList parses a log list in the v3 JSON format.
+func ParseCTLogList(data []byte) (CTLogList, error) {
+	var list ctLogListJSON
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("unmarshal CT log list: %w", err)
+	}
+
+	logs := make(CTLogList)
+	for _, op := range list.Operators {
+		for _, l := range slices.Concat(op.Logs, op.TiledLogs) {
+			key, err := x509.ParsePKIXPublicKey(l.Key)
+			if err != nil {
+				return nil, fmt.Errorf("parse key of CT log %q: %w", l.Description, err)
+			}
+			logs[sha256.Sum256(l.Key)] = &CTLog{
+				Description: l.Description,
+				Operator:    op.Name,
+				Key:         key,
+			}
+		}
+	}
+	return logs, nil
+}
+
+// LoadCTLogList reads a log list from the path. No list is bundled as a stale
+// one would report SCTs of new logs as unknown.
+func LoadCTLogList(path string) (CTLogList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CT log list: %w", err)
+	}
+	return ParseCTLogList(data)
+}
+
+// SCTCheck is the verification result of a single SCT.
+type SCTCheck struct {
+	SCT SCT
+
+	// Log that issued the SCT, nil if it's not in the log list
+	Log   *CTLog
+	Error error
+}
+
+// CTResult holds SCTs of a leaf certificate and their verification results.
+type CTResult struct {
+	SCTs []SCTCheck
+}
+
+// Valid returns the number of valid SCTs.
+func (r *CTResult) Valid() int {
+	n := 0
+	for _, c := range r.SCTs {
+		if c.Error == nil {
+			n++
+		}
+	}
+	return n
+}
+
+// Operators returns the number of distinct operators with valid SCTs.
+func (r *CTResult) Operators() int {
+	var operators []string
+	for _, c := range r.SCTs {
+		if c.Error == nil && !slices.Contains(operators, c.Log.Operator) {
+			operators = append(operators, c.Log.Operator)
+		}
+	}
+	return len(operators)
+}
+
+// Compliant reports whether enough distinct operators logged the certificate.
+func (r *CTResult) Compliant() bool {
+	return r.Operators() >= minCTOperators
+}
+
+// CheckCT verifies embedded SCTs of the certificate and SCTs delivered
+// separately, e.g. in the TLS handshake. The issuer is needed to verify
+// embedded SCTs, which are signed over the precertificate.
+func CheckCT(cert, issuer *Certificate, delivered []SCT, logs CTLogList) *CTResult {
+	res := &CTResult{}
+
+	embedded, err := embeddedSCTs(cert.inner)
+	if err != nil {
+		res.SCTs = append(res.SCTs, SCTCheck{SCT: SCT{Source: SCTSourceCertificate}, Error: err})
+	}
+
+	for _, sct := range slices.Concat(embedded, delivered) {
+		check := SCTCheck{SCT: sct, Log: logs[sct.LogID]}
+		if check.Log == nil {
+			check.Error = errors.New("unknown log")
+		} else {
+			check.Error = verifySCT(sct, cert, issuer, check.Log)
+		}
+		res.SCTs = append(res.SCTs, check)
+	}
+
+	return res
+}
+
+// verifySCT checks the log signature over the certificate entry, see RFC 6962
+// section 3.2.
+func verifySCT(sct SCT, cert, issuer *Certificate, ctLog *CTLog) error {
+	var entry []byte
+	if sct.Source == SCTSourceCertificate {
+		if issuer == nil {
+			return errors.New("issuer certificate is needed to verify embedded SCT")
+		}
+
+		tbs, err := precertTBS(cert.inner)
+		if err != nil {
+			return err
+		}
+		keyHash := sha256.Sum256(issuer.inner.RawSubjectPublicKeyInfo)
+
+		entry = binary.BigEndian.AppendUint16(entry, 1) // precert_entry
+		entry = append(entry, keyHash[:]...)
+		entry = appendVector24(entry, tbs)
+	} else {
+		entry = binary.BigEndian.AppendUint16(entry, 0) // x509_entry
+		entry = appendVector24(entry, cert.inner.Raw)
+	}
+
+	var signed []byte
+	signed = append(signed, sct.Version, 0) // certificate_timestamp
+	signed = binary.BigEndian.AppendUint64(signed, sct.timestamp)
+	signed = append(signed, entry...)
+	signed = binary.BigEndian.AppendUint16(signed, uint16(len(sct.Extensions)))
+	signed = append(signed, sct.Extensions...)
+
+	if sct.HashAlgorithm != 4 {
+		return fmt.Errorf("unsupported hash algorithm %d", sct.HashAlgorithm)
+	}
+	digest := sha256.Sum256(signed)
+
+	switch key := ctLog.Key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(key, digest[:], sct.Signature) {
+			return errors.New("invalid signature")
+		}
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], sct.Signature); err != nil {
+			return errors.New("invalid signature")
+		}
+	default:
+		return fmt.Errorf("unsupported log key %T", key)
+	}
+	return nil
+}
+
+// tbsCertificate mirrors the ASN.1 structure of x509 TBSCertificate so that
+// the SCT extension can be removed from it.
+type tbsCertificate struct {
+	Raw                asn1.RawContent
+	Version            int `asn1:"optional,explicit,default:0,tag:0"`
+	SerialNumber       *big.Int
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Issuer             asn1.RawValue
+	Validity           asn1.RawValue
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	UniqueID           asn1.BitString   `asn1:"optional,tag:1"`
+	SubjectUniqueID    asn1.BitString   `asn1:"optional,tag:2"`
+	Extensions         []pkix.Extension `asn1:"omitempty,optional,explicit,tag:3"`
+}
+
+// precertTBS reconstructs the TBSCertificate the log signed by removing the
+// SCT list extension.
+func precertTBS(cert *x509.Certificate) ([]byte, error) {
+	var tbs tbsCertificate
+	if _, err := asn1.Unmarshal(cert.RawTBSCertificate, &tbs); err != nil {
+		return nil, fmt.Errorf("unmarshal TBS certificate: %w", err)
+	}
+
+	tbs.Raw = nil
+	tbs.Extensions = slices.DeleteFunc(tbs.Extensions, func(ext pkix.Extension) bool {
+		return ext.Id.Equal(oidExtSCTList)
+	})
+
+	data, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, fmt.Errorf("marshal TBS certificate: %w", err)
+	}
+	return data, nil
+}
+
+func appendVector24(b, data []byte) []byte {
+	n := len(data)
+	b = append(b, byte(n>>16), byte(n>>8), byte(n))
+	return append(b, data...)
+}
+
+// formatLogID returns the log ID in base64 as used in log lists.
+func formatLogID(id [32]byte) string {
+	return base64.StdEncoding.EncodeToString(id[:])
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// testCTLog signs SCTs like a CT log does.
+type testCTLog struct {
+	key *ecdsa.PrivateKey
+	id  [32]byte
+}
+
+func newTestCTLog(t *testing.T) *testCTLog {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testCTLog{key: key, id: sha256.Sum256(spki)}
+}
+
+// list returns a log list in the v3 JSON format with the log.
+func (l *testCTLog) list(t *testing.T, operator string) []byte {
+	spki, err := x509.MarshalPKIXPublicKey(&l.key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(map[string]any{
+		"operators": []any{map[string]any{
+			"name": operator,
+			"logs": []any{map[string]any{"description": "Test Log", "key": spki}},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// sign returns a serialized SCT for the entry.
+func (l *testCTLog) sign(t *testing.T, entry []byte) []byte {
+	ts := uint64(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC).UnixMilli())
+
+	signed := []byte{0, 0}
+	signed = binary.BigEndian.AppendUint64(signed, ts)
+	signed = append(signed, entry...)
+	signed = append(signed, 0, 0)
+	digest := sha256.Sum256(signed)
+	sig, err := ecdsa.SignASN1(rand.Reader, l.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sct := []byte{0}
+	sct = append(sct, l.id[:]...)
+	sct = binary.BigEndian.AppendUint64(sct, ts)
+	sct = append(sct, 0, 0, 4, 3)
+	sct = binary.BigEndian.AppendUint16(sct, uint16(len(sig)))
+	return append(sct, sig...)
+}
+
+func TestCheckCT(t *testing.T) {
+	ctLog := newTestCTLog(t)
+	logs, err := ParseCTLogList(ctLog.list(t, "Test Operator"))
+	if err != nil {
+		t.Fatalf("parse log list: %v", err)
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:              time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := NewCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leafKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issue := func(scts [][]byte) *Certificate {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			DNSNames:     []string{"example.com"},
+			NotBefore:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:     time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		var list []byte
+		for _, sct := range scts {
+			list = binary.BigEndian.AppendUint16(list, uint16(len(sct)))
+			list = append(list, sct...)
+		}
+		value, err := asn1.Marshal(append(binary.BigEndian.AppendUint16(nil, uint16(len(list))), list...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		template.ExtraExtensions = []pkix.Extension{{Id: oidExtSCTList, Value: value}}
+
+		der, err := x509.CreateCertificate(rand.Reader, template, caTemplate, &leafKey.PublicKey, caKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cert, err := NewCertificate(der)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cert
+	}
+
+	// Sign the precertificate entry, then issue the final certificate with
+	// the SCT embedded.
+	precert := issue(nil)
+	tbs, err := precertTBS(precert.inner)
+	if err != nil {
+		t.Fatalf("precert TBS: %v", err)
+	}
+	keyHash := sha256.Sum256(ca.inner.RawSubjectPublicKeyInfo)
+	entry := binary.BigEndian.AppendUint16(nil, 1)
+	entry = append(entry, keyHash[:]...)
+	entry = appendVector24(entry, tbs)
+	leaf := issue([][]byte{ctLog.sign(t, entry)})
+
+	// SCT delivered over TLS for the final certificate
+	tlsSCT, err := parseSCT(ctLog.sign(t, appendVector24(binary.BigEndian.AppendUint16(nil, 0), leaf.inner.Raw)))
+	if err != nil {
+		t.Fatalf("parse SCT: %v", err)
+	}
+	tlsSCT.Source = SCTSourceTLS
+
+	res := CheckCT(leaf, ca, []SCT{tlsSCT}, logs)
+	if len(res.SCTs) != 2 {
+		t.Fatalf("SCTs == %d, want 2", len(res.SCTs))
+	}
+	for _, c := range res.SCTs {
+		if c.Error != nil {
+			t.Errorf("%s SCT: %v", c.SCT.Source, c.Error)
+		}
+	}
+	if res.Operators() != 1 || res.Compliant() {
+		t.Errorf("Operators == %d, Compliant == %t, want 1 and false", res.Operators(), res.Compliant())
+	}
+
+	// Embedded SCTs can't be verified without the issuer, and SCTs for a
+	// different certificate are invalid.
+	res = CheckCT(precert, ca, []SCT{tlsSCT}, logs)
+	if res.Valid() != 0 {
+		t.Errorf("Valid == %d for a different certificate, want 0", res.Valid())
+	}
+	res = CheckCT(leaf, nil, nil, logs)
+	if res.Valid() != 0 {
+		t.Errorf("Valid == %d without issuer, want 0", res.Valid())
+	}
+
+	// Logs missing from the list are unknown
+	res = CheckCT(leaf, ca, nil, CTLogList{})
+	if len(res.SCTs) != 1 || res.SCTs[0].Log != nil || res.SCTs[0].Error == nil {
+		t.Errorf("SCT from unknown log is not reported: %+v", res.SCTs)
+	}
+}
+
+// TestCheckCTRealSCTs verifies SCTs embedded by real logs, so the
+// precertificate TBS reconstruction is checked against what the logs signed.
+// testdata/ctlogs.json holds the keys of the two logs, labelled by log ID.
+func TestCheckCTRealSCTs(t *testing.T) {
+	bundle, err := Load("testdata/example.com.crt")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	logs, err := LoadCTLogList("testdata/ctlogs.json")
+	if err != nil {
+		t.Fatalf("load log list: %v", err)
+	}
+
+	leaf, issuer := bundle[0], bundle[1]
+	res := CheckCT(leaf, issuer, nil, logs)
+	if len(res.SCTs) != 2 {
+		t.Fatalf("SCTs == %d, want 2", len(res.SCTs))
+	}
+	for _, c := range res.SCTs {
+		if c.Error != nil {
+			t.Errorf("SCT of %s: %v", formatLogID(c.SCT.LogID), c.Error)
+		}
+	}
+	if !res.Compliant() {
+		t.Errorf("Operators == %d, want compliant", res.Operators())
+	}
+
+	// The issuer key hash is part of the signed entry
+	ca, err := Load("testdata/ca.crt")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if res := CheckCT(leaf, ca[0], nil, logs); res.Valid() != 0 {
+		t.Errorf("Valid == %d with the wrong issuer, want 0", res.Valid())
+	}
+}
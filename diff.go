@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/pflag"
+)
+
+// exitDifferent is the diff subcommand exit code when chains differ, like
+// diff(1) does.
+const exitDifferent = 1
+
+// FieldDiff is a difference in a single certificate field. Scalar fields set
+// A and B, list fields set Added, Removed and Changed.
+type FieldDiff struct {
+	Field   string
+	A, B    string
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+// PositionDiff holds differences between certificates at the same position of
+// two chains. A or B is nil if the chain is shorter.
+type PositionDiff struct {
+	Position int
+	A, B     *Certificate
+	Fields   []FieldDiff
+}
+
+// BundleDiff is a field-by-field comparison of two chains.
+type BundleDiff struct {
+	Positions []PositionDiff
+
+	// SharedIntermediates are non-leaf certificates present in both chains
+	SharedIntermediates []*Certificate
+}
+
+// Equal reports whether the chains have no differences.
+func (d *BundleDiff) Equal() bool {
+	for _, p := range d.Positions {
+		if p.A == nil || p.B == nil || len(p.Fields) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Diff compares two chains position by position.
+func Diff(a, b Bundle) *BundleDiff {
+	d := &BundleDiff{}
+	for i := range max(len(a), len(b)) {
+		p := PositionDiff{Position: i}
+		if i < len(a) {
+			p.A = a[i]
+		}
+		if i < len(b) {
+			p.B = b[i]
+		}
+		if p.A != nil && p.B != nil {
+			p.Fields = diffCertificates(p.A, p.B)
+		}
+		d.Positions = append(d.Positions, p)
+	}
+
+	if len(a) > 1 && len(b) > 1 {
+		for _, c := range a[1:] {
+			if slices.ContainsFunc(b[1:], func(o *Certificate) bool { return o.fingerprint == c.fingerprint }) {
+				d.SharedIntermediates = append(d.SharedIntermediates, c)
+			}
+		}
+	}
+
+	return d
+}
+
+func diffCertificates(a, b *Certificate) []FieldDiff {
+	ca, cb := a.inner, b.inner
+	full := &TextFormatter{Verbosity: FullOutput}
+
+	var diffs []FieldDiff
+	scalar := func(field, va, vb string) {
+		if va != vb {
+			diffs = append(diffs, FieldDiff{Field: field, A: va, B: vb})
+		}
+	}
+	list := func(field string, va, vb []string) {
+		fd := FieldDiff{
+			Field:   field,
+			Added:   missingFrom(vb, va),
+			Removed: missingFrom(va, vb),
+		}
+		if len(fd.Added) > 0 || len(fd.Removed) > 0 {
+			diffs = append(diffs, fd)
+		}
+	}
+
+	scalar("Subject", ca.Subject.String(), cb.Subject.String())
+	list("SANs", full.collectSANs(certSANs(ca)), full.collectSANs(certSANs(cb)))
+	scalar("Issuer", ca.Issuer.String(), cb.Issuer.String())
+	scalar("Serial", fmt.Sprintf("%X", ca.SerialNumber), fmt.Sprintf("%X", cb.SerialNumber))
+	scalar("Not Before", ca.NotBefore.String(), cb.NotBefore.String())
+	scalar("Not After", ca.NotAfter.String(), cb.NotAfter.String())
+	scalar("Validity", validity(ca).String(), validity(cb).String())
+	scalar("Key", formatKeyInfo(ca.PublicKey), formatKeyInfo(cb.PublicKey))
+	scalar("Signature", ca.SignatureAlgorithm.String(), cb.SignatureAlgorithm.String())
+	list("Key Usage", splitList(formatKeyUsage(ca.KeyUsage)), splitList(formatKeyUsage(cb.KeyUsage)))
+	list("Ext Key Usage",
+		splitList(formatExtKeyUsage(ca.ExtKeyUsage, ca.UnknownExtKeyUsage)),
+		splitList(formatExtKeyUsage(cb.ExtKeyUsage, cb.UnknownExtKeyUsage)))
+
+	ext := FieldDiff{Field: "Extensions"}
+	for _, e := range ca.Extensions {
+		i := slices.IndexFunc(cb.Extensions, func(o pkix.Extension) bool { return o.Id.Equal(e.Id) })
+		switch {
+		case i < 0:
+			ext.Removed = append(ext.Removed, extensionName(e))
+		case e.Critical != cb.Extensions[i].Critical || !bytes.Equal(e.Value, cb.Extensions[i].Value):
+			ext.Changed = append(ext.Changed, extensionName(e))
+		}
+	}
+	for _, e := range cb.Extensions {
+		if !slices.ContainsFunc(ca.Extensions, func(o pkix.Extension) bool { return o.Id.Equal(e.Id) }) {
+			ext.Added = append(ext.Added, extensionName(e))
+		}
+	}
+	if len(ext.Added) > 0 || len(ext.Removed) > 0 || len(ext.Changed) > 0 {
+		diffs = append(diffs, ext)
+	}
+
+	return diffs
+}
+
+// missingFrom returns items of a that are not in b.
+func missingFrom(a, b []string) []string {
+	var missing []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			missing = append(missing, s)
+		}
+	}
+	return missing
+}
+
+// splitList splits comma separated output of format functions.
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ", ")
+}
+
+// FormatDiff prints differences per chain position.
+func (f *TextFormatter) FormatDiff(out io.Writer, d *BundleDiff) error {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+
+	for _, p := range d.Positions {
+		if err := w.Flush(); err != nil {
+			return fmt.Errorf("tabwriter failed: %w", err)
+		}
+
+		status := printBool(p.A != nil && p.B != nil && len(p.Fields) == 0)
+		prefix := fmt.Sprintf("--- [%d] %s%s%s %s ", p.Position, ansiBold, diffTitle(p), ansiReset, status)
+		fmt.Fprintf(out, "%s%s\n", prefix, strings.Repeat("-", max(headerWidth-len(prefix), 3)))
+
+		switch {
+		case p.A == nil:
+			fmt.Fprintf(w, "Only in B:\t%s\n", p.B.inner.Subject)
+		case p.B == nil:
+			fmt.Fprintf(w, "Only in A:\t%s\n", p.A.inner.Subject)
+		case len(p.Fields) == 0:
+			fmt.Fprintf(w, "Identical:\t%X\n", p.A.fingerprint)
+		}
+
+		for _, fd := range p.Fields {
+			if fd.A != "" || fd.B != "" {
+				fmt.Fprintf(w, "%s:\t%s -> %s\n", fd.Field, fd.A, fd.B)
+				continue
+			}
+
+			var items []string
+			for _, a := range fd.Added {
+				items = append(items, ansiGreen+"+"+a+ansiReset)
+			}
+			for _, r := range fd.Removed {
+				items = append(items, ansiRed+"-"+r+ansiReset)
+			}
+			for _, c := range fd.Changed {
+				items = append(items, "~"+c)
+			}
+			fmt.Fprintf(w, "%s:\t%s\n", fd.Field, strings.Join(items, ", "))
+		}
+		fmt.Fprintf(w, "\n")
+	}
+
+	var shared []string
+	for _, c := range d.SharedIntermediates {
+		shared = append(shared, displayName(c.inner.Subject))
+	}
+	if len(shared) == 0 {
+		shared = []string{"none"}
+	}
+	fmt.Fprintf(w, "Shared intermediates:\t%s\n", strings.Join(shared, ", "))
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("tabwriter failed: %w", err)
+	}
+	return nil
+}
+
+func diffTitle(p PositionDiff) string {
+	name := func(c *Certificate) string {
+		if c == nil {
+			return "(none)"
+		}
+		return displayName(c.inner.Subject)
+	}
+
+	a, b := name(p.A), name(p.B)
+	if a == b {
+		return a
+	}
+	return a + " -> " + b
+}
+
+// runDiff implements the diff subcommand.
+func runDiff(args []string) int {
+	flags := pflag.NewFlagSet("diff", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s diff <file or URL> <file or URL>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nCompare two certificate chains field by field. Exits with 1 if they differ.\n")
+	}
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	var bundles [2]Bundle
+	for i, source := range flags.Args() {
+		var err error
+		bundles[i], err = Load(source)
+		if err != nil {
+			log.Printf("failed to load from %v: %v", source, err)
+			return ExitLoadFailed
+		}
+	}
+
+	d := Diff(bundles[0], bundles[1])
+	if err := (&TextFormatter{}).FormatDiff(os.Stdout, d); err != nil {
+		log.Printf("formatting: %v", err)
+		return ExitLoadFailed
+	}
+
+	if !d.Equal() {
+		return exitDifferent
+	}
+	return ExitOK
+}
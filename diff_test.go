@@ -0,0 +1,50 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	leaf, err := Load(filepath.Join("testdata", "example.com.crt"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	ca, err := Load(filepath.Join("testdata", "ca.crt"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	d := Diff(leaf, leaf)
+	if !d.Equal() {
+		t.Errorf("Diff of the same chain is not equal: %+v", d.Positions)
+	}
+	if len(d.SharedIntermediates) != len(leaf)-1 {
+		t.Errorf("SharedIntermediates == %d, want %d", len(d.SharedIntermediates), len(leaf)-1)
+	}
+
+	d = Diff(leaf, ca)
+	if d.Equal() {
+		t.Fatalf("Diff of different chains is equal")
+	}
+	if len(d.Positions) != len(leaf) {
+		t.Fatalf("Positions == %d, want %d", len(d.Positions), len(leaf))
+	}
+	if d.Positions[1].B != nil {
+		t.Errorf("Positions[1].B is not nil")
+	}
+
+	fields := map[string]FieldDiff{}
+	for _, fd := range d.Positions[0].Fields {
+		fields[fd.Field] = fd
+	}
+	if fd := fields["Subject"]; fd.A != "CN=example.com" {
+		t.Errorf("Subject A == %q, want %q", fd.A, "CN=example.com")
+	}
+	if fd := fields["SANs"]; len(fd.Removed) != 2 || len(fd.Added) != 0 {
+		t.Errorf("SANs diff == %+v, want 2 removed", fd)
+	}
+	if len(d.SharedIntermediates) != 0 {
+		t.Errorf("SharedIntermediates == %d, want 0", len(d.SharedIntermediates))
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+// Exit codes for scripting and CI.
+const (
+	// ExitOK means every certificate was verified
+	ExitOK = 0
+
+	// ExitVerifyFailed means some certificate, request or CRL failed to verify
+	// or has lint errors
+	ExitVerifyFailed = 1
+
+	// ExitUsage means invalid arguments
+	ExitUsage = 2
+
+	// ExitExpiring means some certificate expires within the warning threshold
+	ExitExpiring = 3
+
+	// ExitLoadFailed means some source couldn't be loaded or connected to
+	ExitLoadFailed = 4
+)
+
+// exitPriority orders exit codes from the least to the most severe, so the
+// worst outcome wins when combining results.
+var exitPriority = map[int]int{
+	ExitOK:           0,
+	ExitExpiring:     1,
+	ExitVerifyFailed: 2,
+	ExitLoadFailed:   3,
+	ExitUsage:        4,
+}
+
+// worseExitCode returns the more severe of two exit codes.
+func worseExitCode(a, b int) int {
+	if exitPriority[b] > exitPriority[a] {
+		return b
+	}
+	return a
+}
+
+// ExitCode derives the exit code from verification results.
+func (r Report) ExitCode() int {
+	code := ExitOK
+	for _, rec := range r {
+		switch {
+		case rec.Error != nil, rec.LintFailed():
+			code = worseExitCode(code, ExitVerifyFailed)
+		case rec.Validity.Status == StatusExpiring:
+			code = worseExitCode(code, ExitExpiring)
+		}
+	}
+	return code
+}
+
+// SectionsExitCode combines exit codes of all sections. A section that failed
+// to load yields ExitLoadFailed.
+func SectionsExitCode(sections []Section) int {
+	code := ExitOK
+	for _, s := range sections {
+		if s.Error != nil {
+			code = worseExitCode(code, ExitLoadFailed)
+			continue
+		}
+		code = worseExitCode(code, s.Report.ExitCode())
+	}
+	return code
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExitCode(t *testing.T) {
+	bundle, err := Load(filepath.Join("testdata", "example.com.crt"))
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	testCases := []struct {
+		time       time.Time
+		warnWithin time.Duration
+		want       int
+	}{
+		{time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC), 0, ExitOK},
+		{time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC), 30 * 24 * time.Hour, ExitOK},
+		{time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC), 30 * 24 * time.Hour, ExitExpiring},
+		{time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC), 30 * 24 * time.Hour, ExitVerifyFailed},
+	}
+
+	for _, c := range testCases {
+		report, err := Verify(bundle, &VerifyOptions{Time: c.time, WarnWithin: c.warnWithin})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+
+		if got := report.ExitCode(); got != c.want {
+			t.Errorf("ExitCode(%v) at %v == %d, want %d", c.warnWithin, c.time, got, c.want)
+		}
+	}
+
+	sections := []Section{
+		{Source: "a", Report: Report{{Error: errors.New("verify")}}},
+		{Source: "b", Error: errors.New("connect")},
+	}
+	if got := SectionsExitCode(sections); got != ExitLoadFailed {
+		t.Errorf("SectionsExitCode == %d, want %d", got, ExitLoadFailed)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"strings"
+)
+
+var oidExtBasicConstraints = asn1.ObjectIdentifier{2, 5, 29, 19}
+
+// extensionNames maps well-known X.509 extension OIDs to readable names.
+var extensionNames = map[string]string{
+	"2.5.29.14":               "Subject Key Identifier",
+	"2.5.29.15":               "Key Usage",
+	"2.5.29.17":               "Subject Alternative Name",
+	"2.5.29.18":               "Issuer Alternative Name",
+	"2.5.29.19":               "Basic Constraints",
+	"2.5.29.20":               "CRL Number",
+	"2.5.29.21":               "CRL Reason",
+	"2.5.29.30":               "Name Constraints",
+	"2.5.29.31":               "CRL Distribution Points",
+	"2.5.29.32":               "Certificate Policies",
+	"2.5.29.35":               "Authority Key Identifier",
+	"2.5.29.37":               "Extended Key Usage",
+	"1.3.6.1.5.5.7.1.1":       "Authority Information Access",
+	"1.3.6.1.5.5.7.1.24":      "TLS Feature",
+	"1.3.6.1.4.1.11129.2.4.2": "CT Precertificate SCTs",
+	"1.3.6.1.4.1.11129.2.4.3": "CT Precertificate Poison",
+}
+
+// extensionName returns a readable name of the extension or its OID if the
+// extension is unknown.
+func extensionName(ext pkix.Extension) string {
+	if name, ok := extensionNames[ext.Id.String()]; ok {
+		return name
+	}
+	return ext.Id.String()
+}
+
+// formatExtensionList returns comma separated extension names, marking
+// critical ones.
+func formatExtensionList(exts []pkix.Extension) string {
+	var names []string
+	for _, ext := range exts {
+		name := extensionName(ext)
+		if ext.Critical {
+			name += " (critical)"
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// policyNames maps CA/Browser Forum policy OIDs to readable names.
+var policyNames = map[string]string{
+	"2.5.29.32.0":    "Any Policy",
+	"2.23.140.1.1":   "Extended Validation",
+	"2.23.140.1.2.1": "Domain Validated",
+	"2.23.140.1.2.2": "Organization Validated",
+	"2.23.140.1.2.3": "Individual Validated",
+}
+
+// tlsFeatureNames maps TLS extension numbers used in the TLS Feature extension
+// to their names.
+var tlsFeatureNames = map[int]string{
+	5:  "status_request (Must-Staple)",
+	17: "status_request_v2",
+}
+
+// field is a labeled line of text output. Continuation lines have no label.
+type field struct {
+	label string
+	value string
+}
+
+// extensionFields describes certificate extensions in their order, skipping
+// key usages and SANs that are printed separately. Unknown extensions are shown
+// by OID with a hex value.
+func extensionFields(cert *x509.Certificate) []field {
+	var fields []field
+	for _, ext := range cert.Extensions {
+		var fs []field
+		switch ext.Id.String() {
+		case "2.5.29.15", "2.5.29.17", "2.5.29.37":
+			continue
+		case "2.5.29.19":
+			fs = []field{{"Basic Constraints", formatBasicConstraints(cert)}}
+		case "2.5.29.14":
+			fs = []field{{"Subject Key ID", fmt.Sprintf("%X", cert.SubjectKeyId)}}
+		case "2.5.29.35":
+			fs = []field{{"Authority Key ID", fmt.Sprintf("%X", cert.AuthorityKeyId)}}
+		case "1.3.6.1.5.5.7.1.1":
+			if len(cert.OCSPServer) > 0 {
+				fs = append(fs, field{"OCSP", strings.Join(cert.OCSPServer, ", ")})
+			}
+			if len(cert.IssuingCertificateURL) > 0 {
+				fs = append(fs, field{"CA Issuers", strings.Join(cert.IssuingCertificateURL, ", ")})
+			}
+			if len(fs) == 0 {
+				fs = []field{{extensionName(ext), fmt.Sprintf("%X", ext.Value)}}
+			}
+		case "2.5.29.31":
+			fs = []field{{"CRL Distribution", strings.Join(cert.CRLDistributionPoints, ", ")}}
+		case "2.5.29.32":
+			fs = []field{{"Policies", formatPolicies(cert.Policies)}}
+		case "2.5.29.30":
+			fs = []field{{"Name Constraints", formatNameConstraints(cert)}}
+		case "1.3.6.1.5.5.7.1.24":
+			fs = []field{{"TLS Feature", formatTLSFeature(ext)}}
+		case "1.3.6.1.4.1.11129.2.4.2":
+			fs = sctFields(cert)
+		case "1.3.6.1.4.1.11129.2.4.3":
+			fs = []field{{"CT Poison", "precertificate"}}
+		default:
+			fs = []field{{extensionName(ext), fmt.Sprintf("%X", ext.Value)}}
+		}
+
+		if ext.Critical {
+			fs[0].value += " (critical)"
+		}
+		fields = append(fields, fs...)
+	}
+	return fields
+}
+
+func formatBasicConstraints(cert *x509.Certificate) string {
+	if !cert.IsCA {
+		return "CA: false"
+	}
+	if cert.MaxPathLen > 0 || cert.MaxPathLenZero {
+		return fmt.Sprintf("CA: true, path length: %d", cert.MaxPathLen)
+	}
+	return "CA: true"
+}
+
+func formatPolicies(policies []x509.OID) string {
+	var names []string
+	for _, oid := range policies {
+		s := oid.String()
+		if name, ok := policyNames[s]; ok {
+			s += " (" + name + ")"
+		}
+		names = append(names, s)
+	}
+	return strings.Join(names, ", ")
+}
+
+func formatNameConstraints(cert *x509.Certificate) string {
+	list := func(dns []string, ips []*net.IPNet, emails, uris []string) string {
+		var names []string
+		for _, d := range dns {
+			names = append(names, "DNS:"+d)
+		}
+		for _, ip := range ips {
+			names = append(names, "IP:"+ip.String())
+		}
+		for _, e := range emails {
+			names = append(names, "Email:"+e)
+		}
+		for _, u := range uris {
+			names = append(names, "URI:"+u)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	var parts []string
+	if s := list(cert.PermittedDNSDomains, cert.PermittedIPRanges, cert.PermittedEmailAddresses, cert.PermittedURIDomains); s != "" {
+		parts = append(parts, "permitted "+s)
+	}
+	if s := list(cert.ExcludedDNSDomains, cert.ExcludedIPRanges, cert.ExcludedEmailAddresses, cert.ExcludedURIDomains); s != "" {
+		parts = append(parts, "excluded "+s)
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatTLSFeature(ext pkix.Extension) string {
+	var features []int
+	if _, err := asn1.Unmarshal(ext.Value, &features); err != nil {
+		return fmt.Sprintf("invalid: %v", err)
+	}
+
+	var names []string
+	for _, f := range features {
+		if name, ok := tlsFeatureNames[f]; ok {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprint(f))
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// sctFields lists embedded SCTs, one per line.
+func sctFields(cert *x509.Certificate) []field {
+	scts, err := embeddedSCTs(cert)
+	if err != nil {
+		return []field{{"SCTs", fmt.Sprintf("invalid: %v", err)}}
+	}
+
+	fields := []field{{"SCTs", fmt.Sprintf("%d embedded", len(scts))}}
+	for _, sct := range scts {
+		fields = append(fields, field{"", fmt.Sprintf("%s %s %s",
+			formatLogID(sct.LogID),
+			sct.Timestamp.Format("2006-01-02 15:04:05"),
+			sct.Algorithm())})
+	}
+	return fields
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Field is a value of a record selectable with --fields. Names are snake_case
+// so that they can be shared with structured output formats.
+type Field struct {
+	Name        string
+	Description string
+	Value       func(rec *TemplateRecord) string
+}
+
+// Fields lists the selectable fields in the order --list-fields prints them.
+var Fields = []Field{
+	{"source", "file, URL or target the record was loaded from", func(r *TemplateRecord) string {
+		return r.Source
+	}},
+	{"type", "certificate, request or crl, empty if the source failed to load", func(r *TemplateRecord) string {
+		switch {
+		case r.Request != nil:
+			return "request"
+		case r.CRL != nil:
+			return "crl"
+		case r.Cert != nil:
+			return "certificate"
+		default:
+			return ""
+		}
+	}},
+	{"subject", "subject distinguished name", func(r *TemplateRecord) string {
+		return r.Subject.String()
+	}},
+	{"common_name", "subject common name", func(r *TemplateRecord) string {
+		return r.Subject.CommonName
+	}},
+	{"issuer", "issuer distinguished name", func(r *TemplateRecord) string {
+		return r.Issuer.String()
+	}},
+	{"sans", "DNS names, IP addresses, emails and URIs, comma-separated", func(r *TemplateRecord) string {
+		return strings.Join(r.Names, ",")
+	}},
+	{"serial", "serial number or CRL number in hex", func(r *TemplateRecord) string {
+		if r.SerialNumber == nil {
+			return ""
+		}
+		return fmt.Sprintf("%X", r.SerialNumber)
+	}},
+	{"not_before", "start of validity or CRL this update, RFC 3339", func(r *TemplateRecord) string {
+		return formatFieldTime(r.NotBefore)
+	}},
+	{"not_after", "end of validity or CRL next update, RFC 3339", func(r *TemplateRecord) string {
+		return formatFieldTime(r.NotAfter)
+	}},
+	{"expires_in_days", "whole days until expiry, negative if expired", func(r *TemplateRecord) string {
+		if r.failed() {
+			return ""
+		}
+		return strconv.Itoa(int(time.Duration(r.Validity.ExpiresIn) / (24 * time.Hour)))
+	}},
+	{"status", "validity status: ok, expiring or expired", func(r *TemplateRecord) string {
+		if r.failed() {
+			return ""
+		}
+		return r.Validity.Status.String()
+	}},
+	{"error", "load or verification error, empty if verified", func(r *TemplateRecord) string {
+		if r.Error == nil {
+			return ""
+		}
+		return r.Error.Error()
+	}},
+	{"fingerprint", "SHA-256 fingerprint of the certificate in hex", func(r *TemplateRecord) string {
+		if r.X509 == nil {
+			return ""
+		}
+		return fmt.Sprintf("%X", r.Fingerprint)
+	}},
+	{"key", "public key algorithm and size", func(r *TemplateRecord) string {
+		switch {
+		case r.Request != nil:
+			return formatKeyInfo(r.Request.inner.PublicKey)
+		case r.X509 != nil:
+			return formatKeyInfo(r.X509.PublicKey)
+		default:
+			return ""
+		}
+	}},
+	{"signature", "signature algorithm", func(r *TemplateRecord) string {
+		switch {
+		case r.Request != nil:
+			return r.Request.inner.SignatureAlgorithm.String()
+		case r.CRL != nil:
+			return r.CRL.inner.SignatureAlgorithm.String()
+		case r.X509 != nil:
+			return r.X509.SignatureAlgorithm.String()
+		default:
+			return ""
+		}
+	}},
+	{"is_ca", "true for CA certificates", func(r *TemplateRecord) string {
+		if r.failed() {
+			return ""
+		}
+		return strconv.FormatBool(r.X509 != nil && r.X509.IsCA)
+	}},
+	{"is_root", "true for trusted roots", func(r *TemplateRecord) string {
+		if r.failed() {
+			return ""
+		}
+		return strconv.FormatBool(r.IsRoot)
+	}},
+}
+
+// failed reports whether the record only holds the error of a source that
+// failed to load.
+func (r *TemplateRecord) failed() bool {
+	return r.Cert == nil && r.Request == nil && r.CRL == nil
+}
+
+func formatFieldTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// DefaultFields are printed by the tsv and csv formats without --fields.
+var DefaultFields = []string{"source", "common_name", "not_after", "status", "fingerprint", "error"}
+
+// LookupFields returns the fields with the names or an error listing the
+// unknown ones.
+func LookupFields(names []string) ([]Field, error) {
+	var fields []Field
+	var unknown []string
+	for _, name := range names {
+		i := fieldIndex(name)
+		if i < 0 {
+			unknown = append(unknown, name)
+			continue
+		}
+		fields = append(fields, Fields[i])
+	}
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("unknown fields %s, see --list-fields", strings.Join(unknown, ", "))
+	}
+	return fields, nil
+}
+
+func fieldIndex(name string) int {
+	for i, f := range Fields {
+		if f.Name == strings.ToLower(strings.TrimSpace(name)) {
+			return i
+		}
+	}
+	return -1
+}
+
+// PrintFields writes the field names with their descriptions.
+func PrintFields(w io.Writer) {
+	for _, f := range Fields {
+		fmt.Fprintf(w, "%-16s %s\n", f.Name, f.Description)
+	}
+}
+
+// FieldsFormatter writes a line per record with the selected fields separated
+// by tabs or, with CSV set, as CSV records.
+type FieldsFormatter struct {
+	Fields []Field
+	CSV    bool
+}
+
+func (f *FieldsFormatter) Format(w io.Writer, report Report) error {
+	if f.CSV {
+		cw := csv.NewWriter(w)
+		for _, rec := range report {
+			if err := cw.Write(f.values(rec)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+
+	// Tabs and newlines in values would break the columns
+	clean := strings.NewReplacer("\t", " ", "\n", " ")
+	for _, rec := range report {
+		values := f.values(rec)
+		for i, v := range values {
+			values[i] = clean.Replace(v)
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(values, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FieldsFormatter) values(rec *Record) []string {
+	t := newTemplateRecord(rec)
+	values := make([]string, len(f.Fields))
+	for i, field := range f.Fields {
+		values[i] = field.Value(t)
+	}
+	return values
+}
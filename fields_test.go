@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFieldsFormat(t *testing.T) {
+	in, err := LoadInput("testdata/example.com.crt")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	crl, err := LoadInput("testdata/ca.crl")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	leaf := &Record{
+		Cert:     in.Bundle[0],
+		Validity: Validity{ExpiresIn: Duration(-36 * time.Hour), Status: StatusExpired},
+		Error:    errors.New("expired\tfor good"),
+		Source:   "example.com.crt",
+	}
+	failed := &Record{Source: "example.net:443", Error: errors.New("connection refused")}
+	report := Report{leaf, {CRL: crl.CRLs[0]}, failed}
+
+	tests := []struct {
+		name   string
+		fields []string
+		csv    bool
+		want   string
+	}{
+		{
+			"tsv",
+			[]string{"source", "common_name", "not_after", "expires_in_days", "status", "error"},
+			false,
+			"example.com.crt\texample.com\t2026-05-14T18:57:50Z\t-1\texpired\texpired for good\n" +
+				"\t\t2026-03-10T00:00:00Z\t0\tok\t\n" +
+				"example.net:443\t\t\t\t\tconnection refused\n",
+		},
+		{
+			"csv",
+			[]string{"type", "sans", "is_ca", "issuer"},
+			true,
+			"certificate,\"example.com,*.example.com\",false,\"CN=Cloudflare TLS Issuing ECC CA 3,O=SSL Corporation,C=US\"\n" +
+				"crl,,false,\"CN=Example Test CA,O=Example Inc,C=US\"\n" +
+				",,,\n",
+		},
+	}
+	for _, tt := range tests {
+		fields, err := LookupFields(tt.fields)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var b strings.Builder
+		if err := (&FieldsFormatter{Fields: fields, CSV: tt.csv}).Format(&b, report); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := LookupFields([]string{"subject", "foo"}); err == nil || !strings.Contains(err.Error(), "foo") {
+		t.Errorf("unknown field error == %v, want one naming foo", err)
+	}
+}
@@ -1,6 +1,9 @@
 package main
 
-// Formatter converts a verification report to a string representation.
+import "io"
+
+// Formatter writes a verification report to w. Binary formats like DER are
+// written as is, text formats end with a newline.
 type Formatter interface {
-	Format(report Report) (string, error)
+	Format(w io.Writer, report Report) error
 }
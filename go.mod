@@ -2,12 +2,16 @@ module github.com/alexdzyoba/cert
 
 go 1.24.0
 
-require github.com/spf13/pflag v1.0.10
+require (
+	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de
+	github.com/spf13/pflag v1.0.10
+	golang.org/x/crypto v0.45.0
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
 
 require (
-	github.com/araddon/dateparse v0.0.0-20210429162001-6b43995a97de // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.19 // indirect
 	github.com/rs/zerolog v1.34.0 // indirect
-	golang.org/x/sys v0.12.0 // indirect
+	golang.org/x/sys v0.38.0 // indirect
 )
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// Inventory summarizes certificates found in multiple sections.
+type Inventory struct {
+	Sources      int
+	Certificates int
+	Expiring     int
+	Expired      int
+
+	// Soonest is the certificate that expires next, if any
+	Soonest *InventoryEntry
+
+	// Duplicates lists certificates found in more than one place
+	Duplicates []Duplicate
+
+	// Failed lists sections that failed to load
+	Failed []Section
+}
+
+// InventoryEntry is a record along with the source it was loaded from.
+type InventoryEntry struct {
+	Source string
+	Record *Record
+}
+
+// Duplicate is a certificate found in several sources.
+type Duplicate struct {
+	Fingerprint Fingerprint
+	Name        string
+	Sources     []string
+}
+
+// NewInventory builds an inventory summary from scanned sections.
+func NewInventory(sections []Section) *Inventory {
+	inv := &Inventory{Sources: len(sections)}
+
+	seen := make(map[Fingerprint]*Duplicate)
+	var order []Fingerprint
+
+	for _, s := range sections {
+		if s.Error != nil {
+			inv.Failed = append(inv.Failed, s)
+			continue
+		}
+
+		for _, rec := range s.Report {
+			if rec.Cert == nil {
+				continue
+			}
+			inv.Certificates++
+
+			if rec.Validity.Status == StatusExpiring {
+				inv.Expiring++
+			}
+			if rec.Validity.ExpiresIn < 0 {
+				inv.Expired++
+			} else if inv.Soonest == nil || rec.Validity.ExpiresIn < inv.Soonest.Record.Validity.ExpiresIn {
+				inv.Soonest = &InventoryEntry{Source: s.Source, Record: rec}
+			}
+
+			fp := rec.Cert.fingerprint
+			d, ok := seen[fp]
+			if !ok {
+				d = &Duplicate{Fingerprint: fp, Name: displayName(rec.Cert.inner.Subject)}
+				seen[fp] = d
+				order = append(order, fp)
+			}
+			if !slices.Contains(d.Sources, s.Source) {
+				d.Sources = append(d.Sources, s.Source)
+			}
+		}
+	}
+
+	for _, fp := range order {
+		if d := seen[fp]; len(d.Sources) > 1 {
+			inv.Duplicates = append(inv.Duplicates, *d)
+		}
+	}
+
+	return inv
+}
+
+// FormatInventory prints the inventory summary.
+func (f *TextFormatter) FormatInventory(out io.Writer, inv *Inventory) error {
+	prefix := fmt.Sprintf("=== %sInventory%s ", ansiBold, ansiReset)
+	fmt.Fprintf(out, "%s%s\n", prefix, strings.Repeat("=", max(headerWidth-len(prefix), 3)))
+
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(w, "Sources:\t%d\n", inv.Sources)
+	fmt.Fprintf(w, "Certificates:\t%d\n", inv.Certificates)
+	if inv.Expiring > 0 {
+		fmt.Fprintf(w, "Expiring:\t%d %s\n", inv.Expiring, printStatus(StatusExpiring))
+	}
+	fmt.Fprintf(w, "Expired:\t%d %s\n", inv.Expired, printBool(inv.Expired == 0))
+
+	if inv.Soonest != nil {
+		rec := inv.Soonest.Record
+		fmt.Fprintf(w, "Soonest Expiry:\t%s in %v (%v), %s\n",
+			displayName(rec.Cert.inner.Subject),
+			rec.Validity.ExpiresIn,
+			rec.Cert.inner.NotAfter.Format("2006-01-02"),
+			inv.Soonest.Source)
+	}
+
+	for i, d := range inv.Duplicates {
+		label := ""
+		if i == 0 {
+			label = "Duplicates:"
+		}
+		fmt.Fprintf(w, "%s\t%s %s: %s\n", label, d.Name, shortFingerprint(d.Fingerprint), strings.Join(d.Sources, ", "))
+	}
+
+	for i, sec := range inv.Failed {
+		label := ""
+		if i == 0 {
+			label = "Failed:"
+		}
+		fmt.Fprintf(w, "%s\t%s: %v\n", label, sec.Source, sec.Error)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("tabwriter failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CertOptions describe a certificate to issue.
+type CertOptions struct {
+	Subject pkix.Name
+
+	// Names are SANs: DNS names, IP addresses, emails and URIs
+	Names []string
+
+	NotBefore time.Time
+	Validity  time.Duration
+
+	// KeyUsage and ExtKeyUsage default to usages typical for a CA or a TLS
+	// leaf if empty
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+
+	IsCA bool
+
+	// MaxPathLen limits the chain length below a CA, -1 means unlimited
+	MaxPathLen int
+}
+
+// Template creates a certificate template for the public key with a random
+// serial number. The common name defaults to the first SAN.
+func (o *CertOptions) Template(pub crypto.PublicKey) (*x509.Certificate, error) {
+	serial, err := newSerialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	notBefore := o.NotBefore
+	if notBefore.IsZero() {
+		notBefore = time.Now()
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               o.Subject,
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(o.Validity),
+		KeyUsage:              o.KeyUsage,
+		ExtKeyUsage:           o.ExtKeyUsage,
+		BasicConstraintsValid: true,
+		IsCA:                  o.IsCA,
+	}
+
+	sans, err := parseNames(o.Names)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.DNSNames, tmpl.IPAddresses, tmpl.EmailAddresses, tmpl.URIs = sans.DNSNames, sans.IPAddresses, sans.EmailAddresses, sans.URIs
+
+	if tmpl.Subject.CommonName == "" && len(o.Names) > 0 {
+		tmpl.Subject.CommonName = o.Names[0]
+	}
+
+	if o.IsCA {
+		switch {
+		case o.MaxPathLen == 0:
+			tmpl.MaxPathLenZero = true
+		case o.MaxPathLen > 0:
+			tmpl.MaxPathLen = o.MaxPathLen
+		default:
+			tmpl.MaxPathLen = -1
+		}
+	}
+
+	if tmpl.KeyUsage == 0 {
+		tmpl.KeyUsage = defaultKeyUsage(pub, o.IsCA)
+	}
+	if tmpl.ExtKeyUsage == nil && !o.IsCA {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	}
+
+	return tmpl, nil
+}
+
+// IssueCertificate signs the template for the public key with the issuer key.
+// The certificate is self-signed if issuer is nil.
+func IssueCertificate(tmpl *x509.Certificate, pub crypto.PublicKey, issuer *Certificate, issuerKey crypto.Signer) (*Certificate, error) {
+	parent := tmpl
+	if issuer != nil {
+		parent = issuer.inner
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, pub, issuerKey)
+	if err != nil {
+		return nil, fmt.Errorf("create certificate: %w", err)
+	}
+	return NewCertificate(der)
+}
+
+// newSerialNumber returns a random positive 128-bit serial number, well above
+// the 64 bits of entropy required by the baseline requirements.
+func newSerialNumber() (*big.Int, error) {
+	limit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serial, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("generate serial number: %w", err)
+	}
+	return serial.Add(serial, big.NewInt(1)), nil
+}
+
+// defaultKeyUsage returns key usages for a CA or a TLS leaf. RSA leaves also
+// get key encipherment for RSA key exchange.
+func defaultKeyUsage(pub crypto.PublicKey, isCA bool) x509.KeyUsage {
+	if isCA {
+		return x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature
+	}
+	if _, ok := pub.(*rsa.PublicKey); ok {
+		return x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment
+	}
+	return x509.KeyUsageDigitalSignature
+}
+
+// parseNames sorts SANs by type: IP addresses, emails with "@", URIs with a
+// scheme and DNS names otherwise.
+func parseNames(names []string) (subjectAltNames, error) {
+	var sans subjectAltNames
+	for _, name := range names {
+		switch {
+		case net.ParseIP(name) != nil:
+			sans.IPAddresses = append(sans.IPAddresses, net.ParseIP(name))
+		case strings.Contains(name, "://"):
+			u, err := url.Parse(name)
+			if err != nil {
+				return sans, fmt.Errorf("parse URI SAN: %w", err)
+			}
+			sans.URIs = append(sans.URIs, u)
+		case strings.Contains(name, "@"):
+			sans.EmailAddresses = append(sans.EmailAddresses, name)
+		default:
+			sans.DNSNames = append(sans.DNSNames, name)
+		}
+	}
+	return sans, nil
+}
+
+// ParseSubject parses a distinguished name like "CN=example.com,O=Example,C=US".
+// Supported attributes are CN, O, OU, C, L, ST, STREET and POSTALCODE.
+func ParseSubject(s string) (pkix.Name, error) {
+	var name pkix.Name
+	if s == "" {
+		return name, nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		attr, value, ok := strings.Cut(part, "=")
+		if !ok {
+			return name, fmt.Errorf("invalid subject attribute %q", part)
+		}
+		value = strings.TrimSpace(value)
+
+		switch strings.ToUpper(strings.TrimSpace(attr)) {
+		case "CN":
+			name.CommonName = value
+		case "O":
+			name.Organization = append(name.Organization, value)
+		case "OU":
+			name.OrganizationalUnit = append(name.OrganizationalUnit, value)
+		case "C":
+			name.Country = append(name.Country, value)
+		case "L":
+			name.Locality = append(name.Locality, value)
+		case "ST":
+			name.Province = append(name.Province, value)
+		case "STREET":
+			name.StreetAddress = append(name.StreetAddress, value)
+		case "POSTALCODE":
+			name.PostalCode = append(name.PostalCode, value)
+		default:
+			return name, fmt.Errorf("unsupported subject attribute %q", attr)
+		}
+	}
+	return name, nil
+}
+
+// keyUsageFlags maps key usage names accepted on the command line.
+var keyUsageFlags = map[string]x509.KeyUsage{
+	"digital-signature":  x509.KeyUsageDigitalSignature,
+	"content-commitment": x509.KeyUsageContentCommitment,
+	"key-encipherment":   x509.KeyUsageKeyEncipherment,
+	"data-encipherment":  x509.KeyUsageDataEncipherment,
+	"key-agreement":      x509.KeyUsageKeyAgreement,
+	"cert-sign":          x509.KeyUsageCertSign,
+	"crl-sign":           x509.KeyUsageCRLSign,
+	"encipher-only":      x509.KeyUsageEncipherOnly,
+	"decipher-only":      x509.KeyUsageDecipherOnly,
+}
+
+// extKeyUsageFlags maps extended key usage names accepted on the command line.
+var extKeyUsageFlags = map[string]x509.ExtKeyUsage{
+	"any":              x509.ExtKeyUsageAny,
+	"server-auth":      x509.ExtKeyUsageServerAuth,
+	"client-auth":      x509.ExtKeyUsageClientAuth,
+	"code-signing":     x509.ExtKeyUsageCodeSigning,
+	"email-protection": x509.ExtKeyUsageEmailProtection,
+	"time-stamping":    x509.ExtKeyUsageTimeStamping,
+	"ocsp-signing":     x509.ExtKeyUsageOCSPSigning,
+}
+
+// ParseKeyUsage combines key usages by their names.
+func ParseKeyUsage(names []string) (x509.KeyUsage, error) {
+	var ku x509.KeyUsage
+	for _, name := range names {
+		bit, ok := keyUsageFlags[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown key usage %q (valid: %s)", name, strings.Join(sortedKeys(keyUsageFlags), ", "))
+		}
+		ku |= bit
+	}
+	return ku, nil
+}
+
+// ParseExtKeyUsage converts extended key usage names.
+func ParseExtKeyUsage(names []string) ([]x509.ExtKeyUsage, error) {
+	var eku []x509.ExtKeyUsage
+	for _, name := range names {
+		usage, ok := extKeyUsageFlags[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown extended key usage %q (valid: %s)", name, strings.Join(sortedKeys(extKeyUsageFlags), ", "))
+		}
+		eku = append(eku, usage)
+	}
+	return eku, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// errNoNames is returned when a leaf certificate would have neither a subject
+// nor SANs.
+var errNoNames = errors.New("at least one name or a subject is required")
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseSubject(t *testing.T) {
+	name, err := ParseSubject("CN=example.com, O=Example Inc,OU=Dev,C=US")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if got, want := name.String(), "CN=example.com,OU=Dev,O=Example Inc,C=US"; got != want {
+		t.Errorf("ParseSubject == %q, want %q", got, want)
+	}
+
+	for _, s := range []string{"example.com", "XX=1"} {
+		if _, err := ParseSubject(s); err == nil {
+			t.Errorf("ParseSubject(%q) didn't fail", s)
+		}
+	}
+}
+
+func TestIssueCertificate(t *testing.T) {
+	for _, keyType := range []string{KeyRSA, KeyECDSA, KeyEd25519} {
+		caKey, err := GenerateKey(keyType, 0)
+		if err != nil {
+			t.Fatalf("generate %s key: %v", keyType, err)
+		}
+		caOpts := CertOptions{Subject: pkix.Name{CommonName: "Test CA"}, Validity: time.Hour, IsCA: true, MaxPathLen: 0}
+		caTmpl, err := caOpts.Template(caKey.Public())
+		if err != nil {
+			t.Fatalf("template: %v", err)
+		}
+		ca, err := IssueCertificate(caTmpl, caKey.Public(), nil, caKey)
+		if err != nil {
+			t.Fatalf("issue CA: %v", err)
+		}
+
+		key, err := GenerateKey(keyType, 0)
+		if err != nil {
+			t.Fatalf("generate %s key: %v", keyType, err)
+		}
+		leafOpts := CertOptions{Names: []string{"example.com", "127.0.0.1", "admin@example.com"}, Validity: time.Hour}
+		tmpl, err := leafOpts.Template(key.Public())
+		if err != nil {
+			t.Fatalf("template: %v", err)
+		}
+		leaf, err := IssueCertificate(tmpl, key.Public(), ca, caKey)
+		if err != nil {
+			t.Fatalf("issue leaf: %v", err)
+		}
+
+		c := leaf.inner
+		if c.Subject.CommonName != "example.com" || len(c.IPAddresses) != 1 || len(c.EmailAddresses) != 1 {
+			t.Errorf("%s: unexpected names CN=%q IPs=%v emails=%v", keyType, c.Subject.CommonName, c.IPAddresses, c.EmailAddresses)
+		}
+		if !slices.Equal(c.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}) {
+			t.Errorf("%s: ExtKeyUsage == %v, want server auth", keyType, c.ExtKeyUsage)
+		}
+		if !ca.inner.MaxPathLenZero {
+			t.Errorf("%s: CA path length is not zero", keyType)
+		}
+
+		report, err := Verify(Bundle{leaf, ca}, &VerifyOptions{Time: time.Now(), Roots: Bundle{ca}})
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if got := report.ExitCode(); got != ExitOK {
+			t.Errorf("%s: ExitCode == %d, want %d: %v", keyType, got, ExitOK, report)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+)
+
+const (
+	PEMPrivateKeyType    = "PRIVATE KEY"
+	PEMRSAPrivateKeyType = "RSA PRIVATE KEY"
+	PEMECPrivateKeyType  = "EC PRIVATE KEY"
+)
+
+// Key types for GenerateKey.
+const (
+	KeyRSA     = "rsa"
+	KeyECDSA   = "ecdsa"
+	KeyEd25519 = "ed25519"
+)
+
+// GenerateKey creates a private key of the type. Size is the RSA modulus or
+// the ECDSA curve size in bits, zero picks the default of 2048 and 256 bits.
+// It's ignored for Ed25519.
+func GenerateKey(keyType string, size int) (crypto.Signer, error) {
+	switch keyType {
+	case KeyRSA:
+		if size == 0 {
+			size = 2048
+		}
+		return rsa.GenerateKey(rand.Reader, size)
+	case KeyECDSA:
+		var curve elliptic.Curve
+		switch size {
+		case 0, 256:
+			curve = elliptic.P256()
+		case 384:
+			curve = elliptic.P384()
+		case 521:
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA key size %d (valid: 256, 384, 521)", size)
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	case KeyEd25519:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		return key, err
+	default:
+		return nil, fmt.Errorf("unsupported key type %q (valid: %s, %s, %s)", keyType, KeyRSA, KeyECDSA, KeyEd25519)
+	}
+}
+
+// EncodeKeyPEM encodes the private key as a PKCS #8 PEM block.
+func EncodeKeyPEM(key crypto.Signer) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("marshal private key: %w", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: PEMPrivateKeyType, Bytes: der}), nil
+}
+
+// LoadPrivateKey reads a PEM-encoded PKCS #8, PKCS #1 or SEC 1 private key.
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read private key: %w", err)
+	}
+	return ParsePrivateKey(data)
+}
+
+// ParsePrivateKey decodes the first private key found in PEM data.
+func ParsePrivateKey(data []byte) (crypto.Signer, error) {
+	for block := range PEMBlocks(data) {
+		var (
+			key any
+			err error
+		)
+		switch block.Type {
+		case PEMPrivateKeyType:
+			key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+		case PEMRSAPrivateKeyType:
+			key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+		case PEMECPrivateKeyType:
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("parse private key: %w", err)
+		}
+
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key %T", key)
+		}
+		return signer, nil
+	}
+	return nil, errors.New("no private key found")
+}
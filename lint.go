@@ -0,0 +1,212 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+)
+
+// Severity of a lint finding.
+type Severity int
+
+const (
+	SeverityNotice Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityNotice:
+		return "notice"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Finding is a single rule violation found by the linter.
+type Finding struct {
+	RuleID   string
+	Severity Severity
+	Message  string
+}
+
+func (f Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.RuleID, f.Message)
+}
+
+// LintRule checks a certificate. Check returns a message describing the
+// violation or an empty string if the certificate complies.
+type LintRule struct {
+	ID       string
+	Severity Severity
+	Check    func(cert *x509.Certificate) string
+}
+
+// maxLeafValidity is the longest validity of TLS server certificates allowed
+// by the CA/Browser Forum baseline requirements.
+const maxLeafValidity = 398 * 24 * time.Hour
+
+// minSerialBits is the entropy the baseline requirements demand for serial
+// numbers.
+const minSerialBits = 64
+
+// DefaultLintRules is a subset of the CA/Browser Forum baseline requirements.
+var DefaultLintRules = []LintRule{
+	{
+		ID:       "leaf-max-validity",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if !isTLSLeaf(c) {
+				return ""
+			}
+			if d := c.NotAfter.Sub(c.NotBefore); d > maxLeafValidity {
+				return fmt.Sprintf("validity of %v exceeds 398 days", Duration(d))
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "leaf-san-includes-cn",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			cn := c.Subject.CommonName
+			if c.IsCA || cn == "" {
+				return ""
+			}
+			if slices.Contains(c.DNSNames, cn) {
+				return ""
+			}
+			if ip := net.ParseIP(cn); ip != nil && slices.ContainsFunc(c.IPAddresses, ip.Equal) {
+				return ""
+			}
+			return fmt.Sprintf("common name %q is not among SANs", cn)
+		},
+	},
+	{
+		ID:       "leaf-eku-required",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if !c.IsCA && len(c.ExtKeyUsage) == 0 && len(c.UnknownExtKeyUsage) == 0 {
+				return "extended key usage is missing"
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "leaf-key-usage",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if !c.IsCA && c.KeyUsage&(x509.KeyUsageCertSign|x509.KeyUsageCRLSign) != 0 {
+				return "non-CA certificate has Certificate Sign or CRL Sign key usage"
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "ca-basic-constraints",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			switch {
+			case c.KeyUsage&x509.KeyUsageCertSign != 0 && !c.IsCA:
+				return "certificate with Certificate Sign key usage is not a CA in basic constraints"
+			case c.IsCA && !hasCriticalExtension(c, oidExtBasicConstraints):
+				return "basic constraints extension of a CA is not critical"
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "ca-key-usage",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if c.IsCA && c.KeyUsage&x509.KeyUsageCertSign == 0 {
+				return "CA certificate lacks Certificate Sign key usage"
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "weak-signature-algorithm",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if slices.Contains(weakSignatureAlgorithms, c.SignatureAlgorithm) {
+				return fmt.Sprintf("signed with %s", c.SignatureAlgorithm)
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "rsa-key-size",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if pub, ok := c.PublicKey.(*rsa.PublicKey); ok && pub.N.BitLen() < 2048 {
+				return fmt.Sprintf("RSA key of %d bits is shorter than 2048", pub.N.BitLen())
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "serial-positive",
+		Severity: SeverityError,
+		Check: func(c *x509.Certificate) string {
+			if c.SerialNumber == nil || c.SerialNumber.Sign() <= 0 {
+				return "serial number is not positive"
+			}
+			return ""
+		},
+	},
+	{
+		ID:       "serial-entropy",
+		Severity: SeverityWarning,
+		Check: func(c *x509.Certificate) string {
+			if c.SerialNumber != nil && c.SerialNumber.Sign() > 0 && c.SerialNumber.BitLen() < minSerialBits {
+				return fmt.Sprintf("serial number has %d bits, fewer than %d bits of entropy", c.SerialNumber.BitLen(), minSerialBits)
+			}
+			return ""
+		},
+	},
+}
+
+// Lint runs the rules against the certificate.
+func Lint(cert *Certificate, rules []LintRule) []Finding {
+	var findings []Finding
+	for _, r := range rules {
+		if msg := r.Check(cert.inner); msg != "" {
+			findings = append(findings, Finding{RuleID: r.ID, Severity: r.Severity, Message: msg})
+		}
+	}
+	return findings
+}
+
+// LintFailed reports whether the record has findings of error severity.
+func (r *Record) LintFailed() bool {
+	return slices.ContainsFunc(r.Findings, func(f Finding) bool { return f.Severity == SeverityError })
+}
+
+// isTLSLeaf reports whether the certificate is a leaf usable for TLS servers.
+func isTLSLeaf(c *x509.Certificate) bool {
+	if c.IsCA {
+		return false
+	}
+	return len(c.ExtKeyUsage) == 0 ||
+		slices.Contains(c.ExtKeyUsage, x509.ExtKeyUsageServerAuth) ||
+		slices.Contains(c.ExtKeyUsage, x509.ExtKeyUsageAny)
+}
+
+func hasCriticalExtension(c *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range c.Extensions {
+		if ext.Id.Equal(oid) {
+			return ext.Critical
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLint(t *testing.T) {
+	notBefore := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	serial := new(big.Int).Lsh(big.NewInt(1), 100)
+
+	leaf := func() *x509.Certificate {
+		return &x509.Certificate{
+			SerialNumber:       serial,
+			Subject:            pkix.Name{CommonName: "example.com"},
+			DNSNames:           []string{"example.com"},
+			NotBefore:          notBefore,
+			NotAfter:           notBefore.Add(90 * 24 * time.Hour),
+			KeyUsage:           x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:        []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+			SignatureAlgorithm: x509.ECDSAWithSHA256,
+		}
+	}
+
+	testCases := []struct {
+		name   string
+		modify func(c *x509.Certificate)
+		want   []string
+	}{
+		{"compliant", func(c *x509.Certificate) {}, nil},
+		{"long validity", func(c *x509.Certificate) { c.NotAfter = notBefore.AddDate(2, 0, 0) }, []string{"leaf-max-validity"}},
+		{"cn not in sans", func(c *x509.Certificate) { c.DNSNames = []string{"www.example.com"} }, []string{"leaf-san-includes-cn"}},
+		{"no eku", func(c *x509.Certificate) { c.ExtKeyUsage = nil }, []string{"leaf-eku-required"}},
+		{"sha1", func(c *x509.Certificate) { c.SignatureAlgorithm = x509.SHA1WithRSA }, []string{"weak-signature-algorithm"}},
+		{"short serial", func(c *x509.Certificate) { c.SerialNumber = big.NewInt(42) }, []string{"serial-entropy"}},
+		{"negative serial", func(c *x509.Certificate) { c.SerialNumber = big.NewInt(-1) }, []string{"serial-positive"}},
+		{"leaf cert sign", func(c *x509.Certificate) { c.KeyUsage |= x509.KeyUsageCertSign }, []string{"leaf-key-usage", "ca-basic-constraints"}},
+		{"ca without cert sign", func(c *x509.Certificate) {
+			c.IsCA = true
+			c.Extensions = []pkix.Extension{{Id: oidExtBasicConstraints, Critical: true}}
+		}, []string{"ca-key-usage"}},
+	}
+
+	for _, c := range testCases {
+		cert := leaf()
+		c.modify(cert)
+
+		var got []string
+		for _, f := range Lint(&Certificate{inner: cert}, DefaultLintRules) {
+			got = append(got, f.RuleID)
+		}
+
+		if !slices.Equal(got, c.want) {
+			t.Errorf("%s: Lint == %v, want %v", c.name, got, c.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/url"
 	"os"
@@ -15,9 +16,34 @@ import (
 // Timeout is the default timeout for TLS connections when loading certificates from URLs.
 var Timeout = 5 * time.Second
 
+// Input holds everything decoded from a single source.
+type Input struct {
+	Bundle   Bundle
+	Requests []*Request
+	CRLs     []*RevocationList
+
+	// ServerName is the name used to connect to a TLS server. The leaf
+	// certificate is verified against it.
+	ServerName string
+
+	// SCTs for the leaf delivered in the TLS handshake or in the stapled OCSP
+	// response
+	SCTs []SCT
+}
+
 // Load loads certificates from a file path, stdin ("-"), or URL.
 // If the source is not a valid file, it attempts to connect via TLS.
 func Load(source string) (Bundle, error) {
+	in, err := LoadInput(source)
+	if err != nil {
+		return nil, err
+	}
+	return in.Bundle, nil
+}
+
+// LoadInput loads certificates, certificate requests and revocation lists from
+// a file path, stdin ("-"), or URL. Files may be PEM or DER-encoded.
+func LoadInput(source string) (*Input, error) {
 	var (
 		f   *os.File
 		err error
@@ -30,7 +56,7 @@ func Load(source string) (Bundle, error) {
 	}
 
 	if errors.Is(err, os.ErrNotExist) {
-		return fromURL(source)
+		return fromURL(source, "")
 	} else if err == nil {
 		defer f.Close()
 		return fromReader(f)
@@ -39,10 +65,34 @@ func Load(source string) (Bundle, error) {
 	}
 }
 
-// LoadMulti loads and combines certificates from multiple sources.
+// LoadMulti loads and combines certificates from multiple sources. Sources
+// may be directories or globs, in which case files that can't be parsed are
+// skipped with a warning.
 func LoadMulti(sources []string) (Bundle, error) {
 	var combined Bundle
 	for _, source := range sources {
+		if IsMultiSource(source) {
+			files, failed, err := ExpandSource(source)
+			if err != nil {
+				return nil, err
+			}
+			for _, s := range failed {
+				log.Printf("skipping %q: %v", s.Source, s.Error)
+			}
+
+			for _, file := range files {
+				bundle, err := Load(file)
+				if err != nil {
+					if hasCertExtension(file) {
+						log.Printf("skipping %q: %v", file, err)
+					}
+					continue
+				}
+				combined = append(combined, bundle...)
+			}
+			continue
+		}
+
 		bundle, err := Load(source)
 		if err != nil {
 			return nil, fmt.Errorf("load from %q: %w", source, err)
@@ -53,39 +103,102 @@ func LoadMulti(sources []string) (Bundle, error) {
 	return combined, nil
 }
 
-func fromReader(r io.Reader) (Bundle, error) {
+func fromReader(r io.Reader) (*Input, error) {
 	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read: %w", err)
 	}
 
-	var bundle Bundle
-	i := 0
+	var (
+		in     Input
+		hasPEM bool
+	)
 	for block := range PEMBlocks(data) {
-		cert, err := NewCertificate(block)
-		if err != nil {
-			return nil, fmt.Errorf("certificate %d: %w", i, err)
+		hasPEM = true
+		switch block.Type {
+		case PEMCertType:
+			cert, err := NewCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("certificate %d: %w", len(in.Bundle), err)
+			}
+			in.Bundle = append(in.Bundle, cert)
+		case PEMRequestType, PEMLegacyRequestType:
+			req, err := NewRequest(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("certificate request %d: %w", len(in.Requests), err)
+			}
+			in.Requests = append(in.Requests, req)
+		case PEMCRLType:
+			crl, err := NewRevocationList(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("CRL %d: %w", len(in.CRLs), err)
+			}
+			in.CRLs = append(in.CRLs, crl)
+		case PEMPKCS7Type:
+			p7, err := ParsePKCS7(block.Bytes)
+			if err != nil {
+				return nil, err
+			}
+			in.Bundle = append(in.Bundle, p7.Bundle...)
+			in.CRLs = append(in.CRLs, p7.CRLs...)
 		}
-		bundle = append(bundle, cert)
-		i++
 	}
-	return bundle, nil
+
+	if !hasPEM && len(data) > 0 {
+		return fromDER(data)
+	}
+	return &in, nil
 }
 
-func fromURL(source string) (Bundle, error) {
+// fromDER decodes a single DER-encoded certificate, request, CRL or PKCS #7
+// bundle.
+func fromDER(data []byte) (*Input, error) {
+	if cert, err := NewCertificate(data); err == nil {
+		return &Input{Bundle: Bundle{cert}}, nil
+	}
+	if req, err := NewRequest(data); err == nil {
+		return &Input{Requests: []*Request{req}}, nil
+	}
+	if crl, err := NewRevocationList(data); err == nil {
+		return &Input{CRLs: []*RevocationList{crl}}, nil
+	}
+	if in, err := ParsePKCS7(data); err == nil {
+		return in, nil
+	}
+	return nil, errors.New("data is neither PEM nor DER-encoded certificate, request, CRL or PKCS #7")
+}
+
+// fromURL fetches certificates presented by a TLS server. If serverName is
+// empty, the host from source is used for SNI.
+func fromURL(source, serverName string) (*Input, error) {
 	addr, err := buildTLSAddr(source)
 	if err != nil {
 		return nil, fmt.Errorf("build TLS address: %w", err)
 	}
 
+	if serverName == "" {
+		serverName, _, err = net.SplitHostPort(addr)
+		if err != nil {
+			return nil, fmt.Errorf("split TLS address: %w", err)
+		}
+	}
+
 	dialer := &net.Dialer{Timeout: Timeout}
-	conn, err := tls.DialWithDialer(dialer, "tcp", addr, nil)
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{
+		ServerName: serverName,
+		// Chain and hostname are checked by Verify, so that invalid
+		// certificates are reported instead of failing the handshake.
+		InsecureSkipVerify: true,
+	})
 	if err != nil {
 		return nil, fmt.Errorf("connect to %q: %w", source, err)
 	}
+	defer conn.Close()
+
+	state := conn.ConnectionState()
 
 	var bundle Bundle
-	for _, c := range conn.ConnectionState().PeerCertificates {
+	for _, c := range state.PeerCertificates {
 		cert, err := NewCertificateFromX509(c)
 		if err != nil {
 			return nil, err
@@ -93,7 +206,33 @@ func fromURL(source string) (Bundle, error) {
 		bundle = append(bundle, cert)
 	}
 
-	return bundle, nil
+	return &Input{Bundle: bundle, ServerName: serverName, SCTs: deliveredSCTs(state)}, nil
+}
+
+// deliveredSCTs parses SCTs from the TLS extension and the stapled OCSP
+// response. Malformed ones are skipped with a warning since they don't affect
+// the certificates.
+func deliveredSCTs(state tls.ConnectionState) []SCT {
+	var scts []SCT
+	for _, raw := range state.SignedCertificateTimestamps {
+		sct, err := parseSCT(raw)
+		if err != nil {
+			log.Printf("skipping SCT from TLS handshake: %v", err)
+			continue
+		}
+		sct.Source = SCTSourceTLS
+		scts = append(scts, sct)
+	}
+
+	if len(state.OCSPResponse) > 0 {
+		ocspSCTs, err := stapledSCTs(state.OCSPResponse)
+		if err != nil {
+			log.Printf("skipping SCTs from stapled OCSP response: %v", err)
+		}
+		scts = append(scts, ocspSCTs...)
+	}
+
+	return scts
 }
 
 // buildTLSAddr creates address from source suitable for tls.DialWithDialer
@@ -1,74 +1,387 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/araddon/dateparse"
 	"github.com/spf13/pflag"
 )
 
+// subcommands maps subcommand names to their entry points. Each gets the
+// arguments following its name and returns the exit code.
+var subcommands = map[string]func(args []string) int{
+	"serve":   runServe,
+	"diff":    runDiff,
+	"new":     runNew,
+	"ca":      runCA,
+	"sign":    runSign,
+	"csr":     runCSR,
+	"convert": runConvert,
+	"split":   runSplit,
+	"bundle":  runBundle,
+	"chain":   runChain,
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+
+	os.Exit(run())
+}
+
+// run checks the sources and returns the exit code.
+func run() int {
 	config, err := ParseArguments()
 	if err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		if checkRequested() {
+			fmt.Printf("CERT UNKNOWN - invalid arguments: %v\n", err)
+			return CheckUnknown
+		}
 		log.Printf("failed to parse arguments: %v", err)
 		pflag.Usage()
-		os.Exit(1)
+		return ExitUsage
 	}
 
-	bundle, err := Load(config.Source)
-	if err != nil {
-		log.Fatalf("failed to load from %v: %v", config.Source, err)
+	if config.ListFields {
+		PrintFields(os.Stdout)
+		return ExitOK
+	}
+
+	if config.Quiet {
+		log.SetOutput(io.Discard)
+	}
+
+	// Nagios plugins must print a status line and exit with 0-3 even if
+	// nothing could be checked
+	fail := func(code int, msg string) int {
+		if config.Check {
+			fmt.Printf("CERT UNKNOWN - %s\n", msg)
+			return CheckUnknown
+		}
+		log.Print(msg)
+		return code
 	}
 
 	roots, err := LoadMulti(config.RootsPath)
 	if err != nil {
-		log.Fatalf("failed to load roots: %v", err)
+		return fail(ExitLoadFailed, fmt.Sprintf("failed to load roots: %v", err))
 	}
 
 	intermediates, err := LoadMulti(config.IntermediatePath)
 	if err != nil {
-		log.Fatalf("failed to load intermediates: %v", err)
+		return fail(ExitLoadFailed, fmt.Sprintf("failed to load intermediates: %v", err))
+	}
+
+	crlIssuers, err := LoadMulti(config.CRLIssuerPath)
+	if err != nil {
+		return fail(ExitLoadFailed, fmt.Sprintf("failed to load CRL issuers: %v", err))
 	}
 
-	report, err := Verify(bundle, &VerifyOptions{
+	opts := &VerifyOptions{
 		Time:          config.Time,
 		Roots:         roots,
 		Intermediates: intermediates,
-	})
+		CRLIssuers:    crlIssuers,
+		WarnWithin:    config.WarnWithin,
+	}
+	if config.Lint {
+		opts.LintRules = DefaultLintRules
+	}
+
+	if config.CT {
+		opts.CTLogs, err = LoadCTLogList(config.CTLogsPath)
+		if err != nil {
+			return fail(ExitLoadFailed, fmt.Sprintf("failed to load CT log list: %v", err))
+		}
+	}
+
+	opts.CryptoPolicy = &config.CryptoPolicy
+
+	Timeout = config.Timeout
+
+	out, err := OpenOutput(config.Output, 0o644)
+	if err != nil {
+		return fail(ExitLoadFailed, err.Error())
+	}
+
+	code, err := runSources(config, opts, out)
+	if err != nil {
+		// Keep the previous output rather than a truncated one
+		out.Abort()
+		log.Printf("formatting: %v", err)
+		return worseExitCode(code, ExitLoadFailed)
+	}
+	if err := out.Close(); err != nil {
+		return fail(worseExitCode(code, ExitLoadFailed), err.Error())
+	}
+	return code
+}
+
+// checkRequested reports whether --check is among the arguments, even if they
+// failed to parse.
+func checkRequested() bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		if arg == "--check" || arg == "--check=true" {
+			return true
+		}
+	}
+	return false
+}
+
+// runSources checks the sources or targets, writes the results to w and
+// returns the exit code. Errors are formatting failures that leave the output
+// incomplete.
+func runSources(config *Config, opts *VerifyOptions, w io.Writer) (int, error) {
+	if config.Check {
+		return runCheck(config, opts, w), nil
+	}
+
+	if config.Watch > 0 {
+		return runWatch(config, opts, w), nil
+	}
+
+	if config.TargetsPath != "" {
+		targets, err := loadTargets(config)
+		if err != nil {
+			log.Printf("failed to load targets: %v", err)
+			return ExitLoadFailed, nil
+		}
+
+		sections := CheckTargets(targets, opts, config.Parallel)
+		code := SectionsExitCode(sections)
+		if !config.Quiet {
+			return code, PrintSummary(w, sections, config)
+		}
+		return code, nil
+	}
+
+	if len(config.Sources) > 1 || IsMultiSource(config.Sources[0]) {
+		sections := CheckSources(config.Sources, opts, config.Parallel)
+		code := SectionsExitCode(sections)
+		if !config.Quiet {
+			return code, PrintSections(w, sections, config)
+		}
+		return code, nil
+	}
+
+	source := config.Sources[0]
+	in, err := LoadInput(source)
+	if err != nil {
+		log.Printf("failed to load from %v: %v", source, err)
+		return ExitLoadFailed, nil
+	}
+
+	report, err := VerifyInput(in, opts)
 	if err != nil {
-		log.Fatalf("failed to verify: %v", err)
+		log.Printf("failed to verify: %v", err)
+		return ExitVerifyFailed, nil
+	}
+	for _, rec := range report {
+		rec.Source = source
 	}
 
-	Print(report, config)
+	if !config.Quiet {
+		return report.ExitCode(), Print(w, report, config)
+	}
+	return report.ExitCode(), nil
+}
+
+// runCheck prints a single Nagios-compatible status line and returns the
+// plugin exit code.
+func runCheck(config *Config, opts *VerifyOptions, w io.Writer) int {
+	warn, crit := config.WarnWithin, config.CritWithin
+	if warn == 0 {
+		warn = defaultCheckWarn
+	}
+	if crit == 0 {
+		crit = defaultCheckCrit
+	}
+	opts.WarnWithin = warn
+
+	var sections []Section
+	if config.TargetsPath != "" {
+		targets, err := loadTargets(config)
+		if err != nil {
+			fmt.Fprintf(w, "CERT UNKNOWN - failed to load targets: %v\n", err)
+			return CheckUnknown
+		}
+		sections = CheckTargets(targets, opts, config.Parallel)
+	} else {
+		sections = CheckSources(config.Sources, opts, config.Parallel)
+	}
+
+	res := NewCheckResult(sections, warn, crit)
+	fmt.Fprintln(w, res)
+	return res.State
+}
+
+// runWatch re-checks sources until interrupted, printing changes.
+func runWatch(config *Config, opts *VerifyOptions, out io.Writer) int {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	w := &Watcher{
+		Sources:   config.Sources,
+		Interval:  config.Watch,
+		Options:   *opts,
+		Formatter: newFormatter(config),
+		Out:       out,
+	}
+	if err := w.Run(ctx); err != nil {
+		log.Printf("failed to watch: %v", err)
+		return ExitVerifyFailed
+	}
+	return ExitOK
+}
+
+// loadTargets reads the targets file and adds positional sources as targets.
+func loadTargets(config *Config) ([]Target, error) {
+	targets, err := LoadTargets(config.TargetsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, source := range config.Sources {
+		targets = append(targets, Target{Addr: source})
+	}
+	return targets, nil
 }
 
 func ParseArguments() (*Config, error) {
 	pflag.Usage = func() {
-		fmt.Fprintf(pflag.CommandLine.Output(), "Usage: %s [options] <file or URL>\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "Usage: %s [options] <file, directory, glob or URL>...\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s serve [options]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s diff <file or URL> <file or URL>\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s new [options] [name...]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s ca init|issue|list [options]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s sign --ca <ca.pem> --ca-key <ca.key> [options] <request.csr>\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s csr [--from <cert.pem>] [options] [name...]\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s convert --to pem|der|p7b|p12 [options] <file>\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s split [options] <file or URL>\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s bundle [--root] [options] <file or URL>...\n", os.Args[0])
+		fmt.Fprintf(pflag.CommandLine.Output(), "       %s chain --pool <dir> [options] <leaf>\n", os.Args[0])
 		fmt.Fprintf(pflag.CommandLine.Output(), "\nOptions:\n")
 		pflag.PrintDefaults()
+		fmt.Fprintf(pflag.CommandLine.Output(), "\nExit codes:\n")
+		fmt.Fprintf(pflag.CommandLine.Output(), "  %d  all certificates verified\n", ExitOK)
+		fmt.Fprintf(pflag.CommandLine.Output(), "  %d  verification or lint failed\n", ExitVerifyFailed)
+		fmt.Fprintf(pflag.CommandLine.Output(), "  %d  usage error\n", ExitUsage)
+		fmt.Fprintf(pflag.CommandLine.Output(), "  %d  certificate expires within --warn-within\n", ExitExpiring)
+		fmt.Fprintf(pflag.CommandLine.Output(), "  %d  failed to load or connect\n", ExitLoadFailed)
 	}
 
-	format := FormatP("format", "f", "text", "Output format - text, pem.")
+	format := FormatP("format", "f", "text", "Output format - text, pem, or tsv and csv with a line per certificate.")
 	timeFlag := pflag.StringP("time", "t", "", "Override date and time for validation.")
 	verbosityFlag := pflag.CountP("verbose", "v", "Increase output verbosity. Can be specified multiple times.")
-	rootsFlag := pflag.StringSliceP("roots", "r", nil, "Path to custom roots. Can be a single certificate or a bundle. Can be specified multiple times.")
-	intermediatesFlag := pflag.StringSliceP("intermediates", "i", nil, "Paths to intermediates. Can be a single certificate or a bundle. Can be specified multiple times.")
-	pflag.Parse()
+	rootsFlag := pflag.StringSliceP("roots", "r", nil, "Path to custom roots. Can be a single certificate, a bundle, a directory or a glob. Can be specified multiple times.")
+	intermediatesFlag := pflag.StringSliceP("intermediates", "i", nil, "Paths to intermediates. Can be a single certificate, a bundle, a directory or a glob. Can be specified multiple times.")
+	targetsFlag := pflag.String("targets", "", "Path to a file with targets to check, one URL or host[:port] per line optionally followed by a server name.")
+	parallelFlag := pflag.Int("parallel", 10, "Number of sources checked concurrently.")
+	timeoutFlag := pflag.Duration("timeout", Timeout, "Timeout for each TLS connection.")
+	warnWithinFlag := DurationP("warn-within", "w", 0, "Warn if a certificate expires within this duration, e.g. 30d, 2w or 12h.")
+	critWithinFlag := DurationP("crit-within", "", 0, "Critical expiry threshold for --check. Defaults to 7d.")
+	checkFlag := pflag.Bool("check", false, "Print a single Nagios-compatible status line and exit with plugin codes 0-3. Warning threshold defaults to 30d.")
+	watchFlag := DurationP("watch", "", 0, "Re-check sources on this interval and print only changes. Modified files are re-checked immediately, new files in directories and globs are picked up.")
+	quietFlag := pflag.BoolP("quiet", "q", false, "Print nothing, only set the exit code.")
+	outputFlag := pflag.String("output", "", "Write output to this file instead of stdout. The file is replaced atomically once everything is written.")
+	crlIssuerFlag := pflag.StringSlice("crl-issuer", nil, "Path to CRL issuer certificate to verify CRL signature. Can be specified multiple times.")
+	minRSABitsFlag := pflag.Int("min-rsa-bits", DefaultCryptoPolicy.MinRSABits, "Warn about RSA keys shorter than this.")
+	weakSignaturesFlag := pflag.StringSlice("weak-signatures", signatureNames(DefaultCryptoPolicy.WeakSignatures), "Warn about these signature algorithms. Pass an empty value to accept all.")
+	weakCurvesFlag := pflag.StringSlice("weak-curves", DefaultCryptoPolicy.WeakCurves, "Warn about ECDSA keys on these curves. Pass an empty value to accept all.")
+	allowDSAFlag := pflag.Bool("allow-dsa", DefaultCryptoPolicy.AllowDSA, "Don't warn about DSA keys.")
+	ctFlag := pflag.Bool("ct", false, "Verify Certificate Transparency SCTs of the leaf against the --ct-logs list.")
+	ctLogsFlag := pflag.String("ct-logs", "", "Path to a CT log list in the v3 JSON format, e.g. downloaded from https://www.gstatic.com/ct/log_list/v3/log_list.json. Implies --ct.")
+	templateFlag := pflag.String("template", "", "Format each certificate with a Go template, e.g. '{{.Subject.CommonName}} {{.Validity.ExpiresIn}}'. A template defined as \"report\" is executed once with all certificates instead.")
+	templateFileFlag := pflag.String("template-file", "", "Read the --template from a file.")
+	fieldsFlag := pflag.StringSliceP("fields", "o", nil, "Fields to print for each certificate, e.g. subject,not_after,fingerprint. Implies --format tsv unless csv is selected. Defaults to "+strings.Join(DefaultFields, ",")+".")
+	listFieldsFlag := pflag.Bool("list-fields", false, "List the fields available for --fields and exit.")
+	lintFlag := pflag.Bool("lint", false, "Check certificates against CA/Browser Forum baseline requirements. Lint errors fail verification.")
+	// Errors are reported by run, as a status line in check mode
+	pflag.CommandLine.Init(os.Args[0], pflag.ContinueOnError)
+	if err := pflag.CommandLine.Parse(os.Args[1:]); err != nil {
+		return nil, err
+	}
+
+	if *listFieldsFlag {
+		return &Config{ListFields: true}, nil
+	}
 
-	// Validate exactly one positional argument
+	// Validate at least one positional argument unless targets are given
 	args := pflag.Args()
-	if len(args) == 0 {
+	if len(args) == 0 && *targetsFlag == "" {
 		return nil, fmt.Errorf("missing required argument: <file or URL>")
 	}
-	if len(args) > 1 {
-		return nil, fmt.Errorf("too many arguments: expected 1, got %d", len(args))
+
+	if *outputFlag != "" && *watchFlag > 0 {
+		return nil, fmt.Errorf("--output can't be used with --watch")
+	}
+
+	if *targetsFlag != "" && *watchFlag > 0 {
+		return nil, fmt.Errorf("--targets can't be used with --watch")
+	}
+
+	if *ctFlag && *ctLogsFlag == "" {
+		return nil, fmt.Errorf("--ct requires a CT log list, pass --ct-logs")
 	}
 
-	source := args[0]
+	tmpl := *templateFlag
+	if *templateFileFlag != "" {
+		if tmpl != "" {
+			return nil, fmt.Errorf("--template can't be used with --template-file")
+		}
+		data, err := os.ReadFile(*templateFileFlag)
+		if err != nil {
+			return nil, fmt.Errorf("read template: %w", err)
+		}
+		// Each record already ends with a newline
+		tmpl = strings.TrimSuffix(string(data), "\n")
+	}
+	if tmpl != "" {
+		if pflag.CommandLine.Changed("format") {
+			return nil, fmt.Errorf("--template can't be used with --format")
+		}
+		if _, err := NewTemplateFormatter(tmpl); err != nil {
+			return nil, err
+		}
+		*format = FormatTemplate
+	}
+
+	if len(*fieldsFlag) > 0 {
+		switch {
+		case tmpl != "":
+			return nil, fmt.Errorf("--fields can't be used with --template")
+		case !pflag.CommandLine.Changed("format"):
+			*format = FormatTSV
+		case *format != FormatTSV && *format != FormatCSV:
+			return nil, fmt.Errorf("--fields can't be used with --format %s", *format)
+		}
+		if _, err := LookupFields(*fieldsFlag); err != nil {
+			return nil, err
+		}
+	}
+
+	policy, err := NewCryptoPolicy(*minRSABitsFlag, *weakSignaturesFlag, *weakCurvesFlag, *allowDSAFlag)
+	if err != nil {
+		return nil, err
+	}
 
 	// Use current time by default
 	t := time.Now()
@@ -87,33 +400,113 @@ func ParseArguments() (*Config, error) {
 	}
 
 	return &Config{
-		Source:           source,
+		Sources:          args,
+		TargetsPath:      *targetsFlag,
+		Parallel:         *parallelFlag,
+		Timeout:          *timeoutFlag,
 		Format:           *format,
+		Template:         tmpl,
+		Fields:           *fieldsFlag,
 		Time:             t,
 		Verbosity:        outputLevel,
 		RootsPath:        *rootsFlag,
 		IntermediatePath: *intermediatesFlag,
+		CRLIssuerPath:    *crlIssuerFlag,
+		WarnWithin:       *warnWithinFlag,
+		CritWithin:       *critWithinFlag,
+		Check:            *checkFlag,
+		Watch:            *watchFlag,
+		Quiet:            *quietFlag,
+		Output:           *outputFlag,
+		Lint:             *lintFlag,
+		CryptoPolicy:     policy,
+		CT:               *ctLogsFlag != "",
+		CTLogsPath:       *ctLogsFlag,
 	}, nil
 }
 
-func Print(report Report, config *Config) {
-	var f Formatter
+// Print writes the report in the configured format.
+func Print(w io.Writer, report Report, config *Config) error {
+	return newFormatter(config).Format(w, report)
+}
+
+// PrintSections writes a report per source or scanned file followed by the
+// inventory summary. Non-text formats get all the records as a single report
+// with their sources set and a record with the error of each failed source.
+func PrintSections(w io.Writer, sections []Section, config *Config) error {
+	if config.Format != FormatText {
+		var report Report
+		for _, s := range sections {
+			if s.Error != nil {
+				// PEM has nothing to encode for a failure
+				if config.Format != FormatPEM {
+					report = append(report, &Record{Source: s.Source, Error: s.Error})
+				}
+				continue
+			}
+			for _, rec := range s.Report {
+				rec.Source = s.Source
+			}
+			report = append(report, s.Report...)
+		}
+		return Print(w, report, config)
+	}
+
+	f := &TextFormatter{Verbosity: config.Verbosity}
+	for _, s := range sections {
+		if s.Error != nil {
+			// Listed in the inventory
+			continue
+		}
+
+		fmt.Fprintf(w, "==> %s <==\n", s.Source)
+		if err := f.Format(w, s.Report); err != nil {
+			return err
+		}
+	}
 
+	return f.FormatInventory(w, NewInventory(sections))
+}
+
+// PrintSummary writes a table with a line per target. Non-text formats get all
+// the records as a single report like in PrintSections.
+func PrintSummary(w io.Writer, sections []Section, config *Config) error {
+	if config.Format != FormatText {
+		return PrintSections(w, sections, config)
+	}
+
+	f := &TextFormatter{Verbosity: config.Verbosity}
+	return f.FormatSummary(w, sections)
+}
+
+func newFormatter(config *Config) Formatter {
 	switch config.Format {
 	case FormatPEM:
-		f = &PEMFormatter{}
+		return &PEMFormatter{}
 	case FormatText:
-		f = &TextFormatter{
+		return &TextFormatter{
 			Verbosity: config.Verbosity,
 		}
+	case FormatTSV, FormatCSV:
+		names := config.Fields
+		if len(names) == 0 {
+			names = DefaultFields
+		}
+		// Validated in ParseArguments already
+		fields, err := LookupFields(names)
+		if err != nil {
+			log.Fatalf("fields: %v", err)
+		}
+		return &FieldsFormatter{Fields: fields, CSV: config.Format == FormatCSV}
+	case FormatTemplate:
+		// Parsed in ParseArguments already
+		f, err := NewTemplateFormatter(config.Template)
+		if err != nil {
+			log.Fatalf("template: %v", err)
+		}
+		return f
 	default:
 		log.Fatalf("unsupported format %v", config.Format)
 	}
-
-	output, err := f.Format(report)
-	if err != nil {
-		log.Fatalf("formatting: %v", err)
-	}
-
-	fmt.Println(output)
+	return nil
 }
@@ -7,6 +7,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/spf13/pflag"
 )
 
 var update = flag.Bool("update", false, "update golden files")
@@ -16,6 +18,8 @@ func TestPEMFormat(t *testing.T) {
 		file string
 	}{
 		{"example.com.crt"},
+		{"example.com.csr"},
+		{"ca.crl"},
 	}
 
 	for _, tt := range tests {
@@ -25,21 +29,27 @@ func TestPEMFormat(t *testing.T) {
 			t.Fatalf("read %s: %v", path, err)
 		}
 
-		bundle, err := Load(path)
+		in, err := LoadInput(path)
 		if err != nil {
 			t.Fatalf("load: %v", err)
 		}
 
 		var report Report
-		for _, c := range bundle {
+		for _, c := range in.Bundle {
 			report = append(report, &Record{Cert: c})
 		}
+		for _, r := range in.Requests {
+			report = append(report, &Record{Request: r})
+		}
+		for _, l := range in.CRLs {
+			report = append(report, &Record{CRL: l})
+		}
 
-		f := &PEMFormatter{}
-		got, err := f.Format(report)
-		if err != nil {
+		var b strings.Builder
+		if err := (&PEMFormatter{}).Format(&b, report); err != nil {
 			t.Fatalf("format: %v", err)
 		}
+		got := b.String()
 
 		// Cut trailing newlines for comparison, since some PEM encoders add
 		// them and some don't.
@@ -57,6 +67,7 @@ func TestTextFormat(t *testing.T) {
 		file      string
 		time      time.Time
 		verbosity OutputLevel
+		crlIssuer string
 		golden    string
 	}{
 		{
@@ -77,29 +88,70 @@ func TestTextFormat(t *testing.T) {
 			verbosity: FullOutput,
 			golden:    "example.com.crt.full.golden",
 		},
+		{
+			file:      "example.com.csr",
+			time:      time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+			verbosity: CompactOutput,
+			golden:    "example.com.csr.golden",
+		},
+		{
+			file:      "example.com.csr",
+			time:      time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+			verbosity: VerboseOutput,
+			golden:    "example.com.csr.verbose.golden",
+		},
+		{
+			file:      "ca.crl",
+			time:      time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+			verbosity: CompactOutput,
+			golden:    "ca.crl.golden",
+		},
+		{
+			file:      "ca.crl.der",
+			time:      time.Date(2026, 3, 16, 12, 0, 0, 0, time.UTC),
+			verbosity: VerboseOutput,
+			crlIssuer: "ca.crt",
+			golden:    "ca.crl.verbose.golden",
+		},
+		{
+			file:      "ca.crl",
+			time:      time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+			verbosity: FullOutput,
+			crlIssuer: "example.com.crt",
+			golden:    "ca.crl.full.golden",
+		},
 	}
 
 	// Fix timezone for deterministic output
 	time.Local = time.UTC
 
 	for _, tt := range tests {
-		bundle, err := Load(filepath.Join("testdata", tt.file))
+		in, err := LoadInput(filepath.Join("testdata", tt.file))
 		if err != nil {
 			t.Fatalf("load %s: %v", tt.file, err)
 		}
 
-		report, err := Verify(bundle, &VerifyOptions{
-			Time: tt.time,
+		var crlIssuers Bundle
+		if tt.crlIssuer != "" {
+			crlIssuers, err = Load(filepath.Join("testdata", tt.crlIssuer))
+			if err != nil {
+				t.Fatalf("load %s: %v", tt.crlIssuer, err)
+			}
+		}
+
+		report, err := VerifyInput(in, &VerifyOptions{
+			Time:       tt.time,
+			CRLIssuers: crlIssuers,
 		})
 		if err != nil {
 			t.Fatalf("verify: %v", err)
 		}
 
-		f := &TextFormatter{Verbosity: tt.verbosity}
-		got, err := f.Format(report)
-		if err != nil {
+		var b strings.Builder
+		if err := (&TextFormatter{Verbosity: tt.verbosity}).Format(&b, report); err != nil {
 			t.Fatalf("format: %v", err)
 		}
+		got := b.String()
 
 		goldenPath := filepath.Join("testdata", tt.golden)
 
@@ -107,7 +159,7 @@ func TestTextFormat(t *testing.T) {
 			if err := os.WriteFile(goldenPath, []byte(got), 0644); err != nil {
 				t.Fatalf("write golden: %v", err)
 			}
-			return
+			continue
 		}
 
 		wantBytes, err := os.ReadFile(goldenPath)
@@ -121,3 +173,49 @@ func TestTextFormat(t *testing.T) {
 		}
 	}
 }
+
+func TestRunSourcesFormatError(t *testing.T) {
+	config := &Config{
+		Sources:  []string{"testdata/ca.crt", "testdata/ca.crl"},
+		Parallel: 1,
+		Format:   FormatTemplate,
+		Template: "{{.X509.Subject.CommonName}}",
+	}
+	opts := &VerifyOptions{Time: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var b strings.Builder
+	if _, err := runSources(config, opts, &b); err == nil {
+		t.Errorf("template failing on a CRL didn't report an error, wrote %q", b.String())
+	}
+}
+
+// parseArguments runs ParseArguments with a fresh flag set.
+func parseArguments(args ...string) (*Config, error) {
+	defer func(args []string, flags *pflag.FlagSet) {
+		os.Args, pflag.CommandLine = args, flags
+	}(os.Args, pflag.CommandLine)
+
+	os.Args = append([]string{"cert"}, args...)
+	pflag.CommandLine = pflag.NewFlagSet("cert", pflag.ContinueOnError)
+	return ParseArguments()
+}
+
+func TestParseArgumentsConflicts(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"--ct", "example.com"}, "--ct-logs"},
+		{[]string{"--targets", "hosts.txt", "--watch", "1m"}, "--targets"},
+	}
+	for _, tt := range tests {
+		if _, err := parseArguments(tt.args...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseArguments(%v) == %v, want error about %s", tt.args, err, tt.want)
+		}
+	}
+
+	config, err := parseArguments("--ct-logs", "testdata/ctlogs.json", "example.com")
+	if err != nil || !config.CT {
+		t.Errorf("--ct-logs == %+v, %v, want CT enabled", config, err)
+	}
+}
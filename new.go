@@ -0,0 +1,304 @@
+package main
+
+import (
+	"crypto"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Default validity of generated certificates.
+const (
+	defaultLeafValidity = 90 * 24 * time.Hour
+	defaultCAValidity   = 10 * 365 * 24 * time.Hour
+)
+
+// runNew implements the new subcommand.
+func runNew(args []string) int {
+	flags := pflag.NewFlagSet("new", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s new [options] [name...]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nGenerate a key and a self-signed or CA-signed certificate for the names.\n")
+		fmt.Fprintf(flags.Output(), "Names are DNS names, IP addresses, emails or URIs put into SANs.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+
+	subjectFlag := flags.String("subject", "", `Subject, e.g. "CN=example.com,O=Example,C=US". The common name defaults to the first name.`)
+	validityFlag := FlagSetDurationP(flags, "validity", "", defaultLeafValidity, "Validity period, e.g. 90d or 1w. Defaults to 10 years for CAs.")
+	keyTypeFlag := flags.String("key-type", KeyECDSA, "Key type - rsa, ecdsa, ed25519.")
+	keySizeFlag := flags.Int("key-size", 0, "RSA key size or ECDSA curve size in bits. Defaults to 2048 for RSA and 256 for ECDSA.")
+	keyUsageFlag := flags.StringSlice("key-usage", nil, "Key usages, e.g. digital-signature,key-encipherment. Defaults depend on the key type and --ca.")
+	extKeyUsageFlag := flags.StringSlice("ext-key-usage", nil, "Extended key usages, e.g. server-auth,client-auth. Defaults to server-auth for leaves.")
+	caFlag := flags.Bool("ca", false, "Generate a CA certificate.")
+	pathLenFlag := flags.Int("path-len", -1, "Maximum number of intermediate CAs below the CA, -1 for unlimited.")
+	issuerFlag := flags.String("issuer", "", "Path to the issuer certificate. The certificate is self-signed if not set.")
+	issuerKeyFlag := flags.String("issuer-key", "", "Path to the issuer private key.")
+	certOutFlag := flags.String("cert-out", "", "Path to write the certificate to. Defaults to <common name>.crt.")
+	keyOutFlag := flags.String("key-out", "", "Path to write the private key to. Defaults to <common name>.key.")
+	forceFlag := flags.Bool("force", false, "Overwrite existing files.")
+	verbosityFlag := flags.CountP("verbose", "v", "Increase output verbosity. Can be specified multiple times.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	subject, err := ParseSubject(*subjectFlag)
+	if err != nil {
+		log.Printf("failed to parse subject: %v", err)
+		return ExitUsage
+	}
+	if subject.CommonName == "" && flags.NArg() == 0 {
+		log.Print(errNoNames)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	verbosity, err := NewOutputLevel(*verbosityFlag)
+	if err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	opts := CertOptions{
+		Subject:    subject,
+		Names:      flags.Args(),
+		Validity:   *validityFlag,
+		IsCA:       *caFlag,
+		MaxPathLen: *pathLenFlag,
+	}
+	if *caFlag && !flags.Changed("validity") {
+		opts.Validity = defaultCAValidity
+	}
+	if opts.KeyUsage, err = ParseKeyUsage(*keyUsageFlag); err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+	if opts.ExtKeyUsage, err = ParseExtKeyUsage(*extKeyUsageFlag); err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	var (
+		issuerChain Bundle
+		issuerKey   crypto.Signer
+	)
+	if *issuerFlag != "" {
+		if *issuerKeyFlag == "" {
+			log.Print("--issuer-key is required with --issuer")
+			return ExitUsage
+		}
+		if issuerChain, err = Load(*issuerFlag); err != nil || len(issuerChain) == 0 {
+			log.Printf("failed to load issuer: %v", err)
+			return ExitLoadFailed
+		}
+		if issuerKey, err = LoadPrivateKey(*issuerKeyFlag); err != nil {
+			log.Printf("failed to load issuer key: %v", err)
+			return ExitLoadFailed
+		}
+	}
+
+	key, err := GenerateKey(*keyTypeFlag, *keySizeFlag)
+	if err != nil {
+		log.Printf("failed to generate key: %v", err)
+		return ExitUsage
+	}
+
+	tmpl, err := opts.Template(key.Public())
+	if err != nil {
+		log.Printf("failed to create certificate: %v", err)
+		return ExitUsage
+	}
+
+	var issuer *Certificate
+	if len(issuerChain) > 0 {
+		issuer = issuerChain[0]
+	} else {
+		issuerKey = key
+	}
+
+	cert, err := IssueCertificate(tmpl, key.Public(), issuer, issuerKey)
+	if err != nil {
+		log.Printf("failed to issue certificate: %v", err)
+		return ExitVerifyFailed
+	}
+
+	name := fileName(cert)
+	certOut, keyOut := *certOutFlag, *keyOutFlag
+	if certOut == "" {
+		certOut = name + ".crt"
+	}
+	if keyOut == "" {
+		keyOut = name + ".key"
+	}
+
+	if err := writeKeyPair(certOut, keyOut, cert, key, *forceFlag); err != nil {
+		log.Print(err)
+		return ExitLoadFailed
+	}
+	log.Printf("wrote %s and %s", certOut, keyOut)
+
+	return printIssued(cert, issuerChain, verbosity)
+}
+
+// writeKeyPair writes the certificate and its private key as PEM files. The
+// key is readable only by the owner. Either both files are written or none.
+func writeKeyPair(certPath, keyPath string, cert *Certificate, key crypto.Signer, force bool) error {
+	keyPEM, err := EncodeKeyPEM(key)
+	if err != nil {
+		return err
+	}
+
+	return writeFiles(force,
+		outputFile{keyPath, keyPEM, 0o600},
+		outputFile{certPath, encodePEMBundle(Bundle{cert}), 0o644},
+	)
+}
+
+// writePEMCertificate writes the certificate as a PEM file.
+func writePEMCertificate(path string, cert *Certificate, force bool) error {
+	return writePEMBundle(path, Bundle{cert}, force)
+}
+
+// writePEMBundle writes the certificates as a PEM file in order.
+func writePEMBundle(path string, bundle Bundle, force bool) error {
+	return writeFile(path, encodePEMBundle(bundle), 0o644, force)
+}
+
+func encodePEMBundle(bundle Bundle) []byte {
+	var data []byte
+	for _, cert := range bundle {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: PEMCertType, Bytes: cert.Bytes()})...)
+	}
+	return data
+}
+
+// writeFile writes data to a file, refusing to overwrite existing ones unless
+// forced.
+func writeFile(path string, data []byte, perm os.FileMode, force bool) error {
+	return writeFiles(force, outputFile{path, data, perm})
+}
+
+// outputFile is a file for writeFiles.
+type outputFile struct {
+	path string
+	data []byte
+	perm os.FileMode
+}
+
+// writeFiles writes either all files or none, refusing to overwrite existing
+// files unless forced. Files are written next to their paths and renamed into
+// place once all are written, so overwritten files get the new permissions. If
+// renaming one fails, the ones already in place are removed or, if forced,
+// restored from links to the files they replaced.
+func writeFiles(force bool, files ...outputFile) (err error) {
+	if !force {
+		for _, f := range files {
+			if _, err := os.Lstat(f.path); err == nil {
+				return &fs.PathError{Op: "create", Path: f.path, Err: fs.ErrExist}
+			}
+		}
+	}
+
+	outs := make([]*Output, 0, len(files))
+	var placed []string
+	backups := make(map[string]string)
+	defer func() {
+		if err != nil {
+			for _, out := range outs {
+				out.Abort()
+			}
+			for _, path := range placed {
+				if backup, ok := backups[path]; ok {
+					os.Rename(backup, path)
+				} else {
+					os.Remove(path)
+				}
+			}
+		}
+		for _, backup := range backups {
+			os.Remove(backup)
+		}
+	}()
+
+	for _, f := range files {
+		out, err := OpenOutput(f.path, f.perm)
+		if err != nil {
+			return err
+		}
+		// Files created since the check above aren't overwritten either
+		out.NoReplace = !force
+		outs = append(outs, out)
+		if _, err := out.Write(f.data); err != nil {
+			return fmt.Errorf("write %s: %w", f.path, err)
+		}
+	}
+	for i, out := range outs {
+		path := files[i].path
+		if force {
+			backup := out.tmp.Name() + ".old"
+			if err := os.Link(path, backup); err == nil {
+				backups[path] = backup
+			} else if !errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("back up %s: %w", path, err)
+			}
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+		placed = append(placed, path)
+	}
+	return nil
+}
+
+// fileName returns a file name without extension for the certificate based on
+// its common name.
+func fileName(cert *Certificate) string {
+	name := cert.inner.Subject.CommonName
+	if name == "" {
+		return shortFingerprint(cert.fingerprint)
+	}
+	return safeFileName(name)
+}
+
+// safeFileName replaces characters of a common name not suitable for file
+// names.
+func safeFileName(name string) string {
+	return strings.NewReplacer("*", "_wildcard", "/", "_", " ", "_", ":", "_").Replace(name)
+}
+
+// printIssued verifies the certificate against its issuer chain, or itself if
+// self-signed, and prints it with the text formatter.
+func printIssued(cert *Certificate, issuerChain Bundle, verbosity OutputLevel) int {
+	roots := issuerChain
+	if len(roots) == 0 {
+		roots = Bundle{cert}
+	}
+
+	report, err := Verify(append(Bundle{cert}, issuerChain...), &VerifyOptions{
+		Time:      time.Now(),
+		Roots:     roots,
+		KeyUsages: cert.inner.ExtKeyUsage,
+	})
+	if err != nil {
+		log.Printf("failed to verify: %v", err)
+		return ExitVerifyFailed
+	}
+
+	if err := (&TextFormatter{Verbosity: verbosity}).Format(os.Stdout, report); err != nil {
+		log.Printf("formatting: %v", err)
+		return ExitVerifyFailed
+	}
+	return ExitOK
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFiles(t *testing.T) {
+	dir := t.TempDir()
+	keyPath, certPath := filepath.Join(dir, "a.key"), filepath.Join(dir, "a.crt")
+	if err := os.WriteFile(certPath, []byte("old cert"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	files := []outputFile{{keyPath, []byte("key"), 0o600}, {certPath, []byte("cert"), 0o644}}
+
+	// Nothing is written if any file exists
+	if err := writeFiles(false, files...); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("writing over an existing file == %v, want exists error", err)
+	}
+	if _, err := os.Stat(keyPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("key written although the certificate exists: %v", err)
+	}
+
+	// Overwritten files get the new permissions
+	if err := os.WriteFile(keyPath, []byte("old key"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := writeFiles(true, files...); err != nil {
+		t.Fatalf("force write: %v", err)
+	}
+	for _, f := range files {
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		info, err := os.Stat(f.path)
+		if err != nil {
+			t.Fatalf("stat: %v", err)
+		}
+		if string(data) != string(f.data) || info.Mode().Perm() != f.perm {
+			t.Errorf("%s == %q with %v, want %q with %v", f.path, data, info.Mode().Perm(), f.data, f.perm)
+		}
+	}
+
+	// Files already in place are rolled back if a later one fails, here as a
+	// non-empty directory can't be replaced
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.MkdirAll(filepath.Join(blocked, "sub"), 0o755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	newPath := filepath.Join(dir, "b.key")
+	err := writeFiles(true, outputFile{keyPath, []byte("new key"), 0o600}, outputFile{newPath, []byte("b"), 0o600}, outputFile{blocked, []byte("x"), 0o644})
+	if err == nil {
+		t.Fatalf("writing over a directory didn't fail")
+	}
+	if data, err := os.ReadFile(keyPath); err != nil || string(data) != "key" {
+		t.Errorf("replaced key == %q, %v, want it restored", data, err)
+	}
+	if _, err := os.Stat(newPath); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("new file left after a failure: %v", err)
+	}
+	if err := os.RemoveAll(blocked); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("temporary files left in %s: %v", dir, entries)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Output is where reports are written: stdout or a file. Files are written to
+// a temporary file next to them and renamed into place on Close, so readers
+// never see partial output.
+type Output struct {
+	w       io.Writer
+	tmp     *os.File
+	path    string
+	written bool
+
+	// NoReplace makes Close fail with fs.ErrExist instead of replacing a file
+	// created in the meantime
+	NoReplace bool
+}
+
+// OpenOutput returns an output writing to the file at path with the
+// permissions, or to stdout if path is empty.
+func OpenOutput(path string, perm os.FileMode) (*Output, error) {
+	if path == "" {
+		return &Output{w: os.Stdout}, nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("create output: %w", err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil, fmt.Errorf("create output: %w", err)
+	}
+	return &Output{w: tmp, tmp: tmp, path: path}, nil
+}
+
+func (o *Output) Write(p []byte) (int, error) {
+	if len(p) > 0 {
+		o.written = true
+	}
+	return o.w.Write(p)
+}
+
+// Abort discards everything written to the file, leaving an existing file
+// untouched.
+func (o *Output) Abort() {
+	if o.tmp == nil {
+		return
+	}
+	o.tmp.Close()
+	os.Remove(o.tmp.Name())
+	o.tmp = nil
+}
+
+// Close replaces the file with everything written so far, unless NoReplace is
+// set and the file exists. If nothing was written, the file is left untouched.
+func (o *Output) Close() error {
+	if o.tmp == nil {
+		return nil
+	}
+	tmp := o.tmp
+	o.tmp = nil
+
+	if !o.written {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return nil
+	}
+
+	err := errors.Join(tmp.Sync(), tmp.Close())
+	if err == nil {
+		if o.NoReplace {
+			// Unlike rename, link fails if the file exists
+			err = os.Link(tmp.Name(), o.path)
+			os.Remove(tmp.Name())
+			if err != nil {
+				return fmt.Errorf("write output: %w", err)
+			}
+			return nil
+		}
+		err = os.Rename(tmp.Name(), o.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("write output: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.txt")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	read := func() string {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		return string(data)
+	}
+
+	out, err := OpenOutput(path, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	fmt.Fprintln(out, "new")
+	if got := read(); got != "old\n" {
+		t.Errorf("file changed before Close: %q", got)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got := read(); got != "new\n" {
+		t.Errorf("file == %q after Close, want %q", got, "new\n")
+	}
+
+	// Aborted and empty outputs leave the file as is
+	out, err = OpenOutput(path, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	fmt.Fprintln(out, "partial")
+	out.Abort()
+
+	out, err = OpenOutput(path, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if got := read(); got != "new\n" {
+		t.Errorf("file == %q, want %q", got, "new\n")
+	}
+
+	// NoReplace outputs don't overwrite files created after opening
+	out, err = OpenOutput(path, 0o644)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	out.NoReplace = true
+	fmt.Fprintln(out, "racing")
+	if err := out.Close(); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("NoReplace close == %v, want exists error", err)
+	}
+	if got := read(); got != "new\n" {
+		t.Errorf("file == %q, want %q", got, "new\n")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left in %s: %v", dir, entries)
+	}
+}
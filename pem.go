@@ -3,20 +3,25 @@ package main
 import (
 	"encoding/pem"
 	"fmt"
+	"io"
 	"iter"
-	"strings"
 )
 
-const PEMCertType = "CERTIFICATE"
+const (
+	PEMCertType    = "CERTIFICATE"
+	PEMRequestType = "CERTIFICATE REQUEST"
+	PEMCRLType     = "X509 CRL"
 
-// PEMBlocks returns iterator that yields parsed certificate blocks in DER format
-func PEMBlocks(data []byte) iter.Seq[[]byte] {
-	return func(yield func([]byte) bool) {
+	// PEMLegacyRequestType is used by older tools such as Netscape and MSIE
+	PEMLegacyRequestType = "NEW CERTIFICATE REQUEST"
+)
+
+// PEMBlocks returns iterator that yields all PEM blocks found in data
+func PEMBlocks(data []byte) iter.Seq[*pem.Block] {
+	return func(yield func(*pem.Block) bool) {
 		for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
-			if block.Type == PEMCertType {
-				if !yield(block.Bytes) {
-					return
-				}
+			if !yield(block) {
+				return
 			}
 		}
 	}
@@ -24,17 +29,24 @@ func PEMBlocks(data []byte) iter.Seq[[]byte] {
 
 type PEMFormatter struct{}
 
-func (f *PEMFormatter) Format(report Report) (string, error) {
-	var b strings.Builder
+func (f *PEMFormatter) Format(w io.Writer, report Report) error {
 	for _, rec := range report {
-		cert := rec.Cert
-		err := pem.Encode(&b, &pem.Block{
-			Type:  PEMCertType,
-			Bytes: cert.Bytes(),
-		})
-		if err != nil {
-			return "", fmt.Errorf("encoding to PEM: %w", err)
+		if err := pem.Encode(w, recordBlock(rec)); err != nil {
+			return fmt.Errorf("encoding to PEM: %w", err)
 		}
 	}
-	return b.String(), nil
+	return nil
+}
+
+// recordBlock returns the DER-encoded certificate, request or CRL of the
+// record as a PEM block.
+func recordBlock(rec *Record) *pem.Block {
+	switch {
+	case rec.Request != nil:
+		return &pem.Block{Type: PEMRequestType, Bytes: rec.Request.Bytes()}
+	case rec.CRL != nil:
+		return &pem.Block{Type: PEMCRLType, Bytes: rec.CRL.Bytes()}
+	default:
+		return &pem.Block{Type: PEMCertType, Bytes: rec.Cert.Bytes()}
+	}
 }
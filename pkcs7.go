@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+)
+
+// PEMPKCS7Type is the PEM block type of PKCS #7 certificate bundles (P7B).
+const PEMPKCS7Type = "PKCS7"
+
+var (
+	oidPKCS7Data       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidPKCS7SignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+)
+
+// pkcs7ContentInfo is the ContentInfo from RFC 2315. The asn1 package ignores
+// tags on RawValue, so Content holds the [0] EXPLICIT wrapper itself.
+type pkcs7ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"optional"`
+}
+
+// pkcs7SignedData is a degenerate SignedData without signers, used only to
+// carry certificates and CRLs. Certificates and CRLs are SETs, but the
+// implicit tags replace the SET tag anyway, and encoding them as sequences
+// keeps the chain order that DER sorting of SET elements would lose.
+type pkcs7SignedData struct {
+	Version          int
+	DigestAlgorithms []asn1.RawValue `asn1:"set"`
+	ContentInfo      pkcs7ContentInfo
+	Certificates     []asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             []asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []asn1.RawValue `asn1:"set"`
+}
+
+// EncodePKCS7 encodes certificates and CRLs as a DER PKCS #7 bundle.
+func EncodePKCS7(bundle Bundle, crls []*RevocationList) ([]byte, error) {
+	sd := pkcs7SignedData{
+		Version:     1,
+		ContentInfo: pkcs7ContentInfo{ContentType: oidPKCS7Data},
+	}
+	for _, cert := range bundle {
+		sd.Certificates = append(sd.Certificates, asn1.RawValue{FullBytes: cert.Bytes()})
+	}
+	for _, crl := range crls {
+		sd.CRLs = append(sd.CRLs, asn1.RawValue{FullBytes: crl.Bytes()})
+	}
+
+	content, err := asn1.Marshal(sd)
+	if err != nil {
+		return nil, fmt.Errorf("marshal PKCS #7 signed data: %w", err)
+	}
+	data, err := asn1.Marshal(pkcs7ContentInfo{
+		ContentType: oidPKCS7SignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal PKCS #7 content info: %w", err)
+	}
+	return data, nil
+}
+
+// ParsePKCS7 decodes certificates and CRLs from a DER PKCS #7 bundle.
+func ParsePKCS7(data []byte) (*Input, error) {
+	var info pkcs7ContentInfo
+	if rest, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("parse PKCS #7: %w", err)
+	} else if len(rest) > 0 {
+		return nil, errors.New("parse PKCS #7: trailing data")
+	}
+	if !info.ContentType.Equal(oidPKCS7SignedData) {
+		return nil, fmt.Errorf("unsupported PKCS #7 content type %s", info.ContentType)
+	}
+
+	var sd pkcs7SignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &sd); err != nil {
+		return nil, fmt.Errorf("parse PKCS #7 signed data: %w", err)
+	}
+
+	var in Input
+	for _, raw := range sd.Certificates {
+		cert, err := NewCertificate(raw.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("certificate %d: %w", len(in.Bundle), err)
+		}
+		in.Bundle = append(in.Bundle, cert)
+	}
+	for _, raw := range sd.CRLs {
+		crl, err := NewRevocationList(raw.FullBytes)
+		if err != nil {
+			return nil, fmt.Errorf("CRL %d: %w", len(in.CRLs), err)
+		}
+		in.CRLs = append(in.CRLs, crl)
+	}
+	return &in, nil
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CryptoPolicy defines which keys and signature algorithms are considered
+// weak.
+type CryptoPolicy struct {
+	// MinRSABits is the shortest acceptable RSA modulus
+	MinRSABits int
+
+	// WeakSignatures are signature algorithms that are no longer secure
+	WeakSignatures []x509.SignatureAlgorithm
+
+	// WeakCurves are names of deprecated elliptic curves, e.g. "P-224"
+	WeakCurves []string
+
+	// AllowDSA accepts DSA keys, which are deprecated by default
+	AllowDSA bool
+}
+
+// weakSignatureAlgorithms rely on broken MD2, MD5 or SHA-1 hashes.
+var weakSignatureAlgorithms = []x509.SignatureAlgorithm{
+	x509.MD2WithRSA,
+	x509.MD5WithRSA,
+	x509.SHA1WithRSA,
+	x509.DSAWithSHA1,
+	x509.ECDSAWithSHA1,
+}
+
+// DefaultCryptoPolicy follows current NIST and browser recommendations.
+var DefaultCryptoPolicy = CryptoPolicy{
+	MinRSABits:     2048,
+	WeakSignatures: weakSignatureAlgorithms,
+	WeakCurves:     []string{"P-224"},
+}
+
+// ellipticCurves are names of the curves crypto/x509 parses keys for.
+var ellipticCurves = []string{"P-224", "P-256", "P-384", "P-521"}
+
+// NewCryptoPolicy returns a policy with signature algorithms and curves given
+// by name, e.g. SHA1-RSA and P-224, as printed in the text output.
+func NewCryptoPolicy(minRSABits int, weakSignatures, weakCurves []string, allowDSA bool) (CryptoPolicy, error) {
+	p := CryptoPolicy{MinRSABits: minRSABits, AllowDSA: allowDSA}
+	for _, name := range weakSignatures {
+		alg, err := ParseSignatureAlgorithm(name)
+		if err != nil {
+			return CryptoPolicy{}, err
+		}
+		p.WeakSignatures = append(p.WeakSignatures, alg)
+	}
+	for _, name := range weakCurves {
+		i := slices.IndexFunc(ellipticCurves, func(c string) bool { return strings.EqualFold(c, name) })
+		if i < 0 {
+			return CryptoPolicy{}, fmt.Errorf("unknown curve %q (valid: %s)", name, strings.Join(ellipticCurves, ", "))
+		}
+		p.WeakCurves = append(p.WeakCurves, ellipticCurves[i])
+	}
+	return p, nil
+}
+
+// ParseSignatureAlgorithm returns the signature algorithm with the name, as
+// crypto/x509 prints it, ignoring case.
+func ParseSignatureAlgorithm(name string) (x509.SignatureAlgorithm, error) {
+	for alg := x509.MD2WithRSA; alg < 64; alg++ {
+		if strings.EqualFold(signatureName(alg), name) {
+			return alg, nil
+		}
+	}
+	return x509.UnknownSignatureAlgorithm, fmt.Errorf("unknown signature algorithm %q", name)
+}
+
+// signatureName is the name of the algorithm, crypto/x509 doesn't name MD2.
+func signatureName(alg x509.SignatureAlgorithm) string {
+	if alg == x509.MD2WithRSA {
+		return "MD2-RSA"
+	}
+	return alg.String()
+}
+
+// signatureNames returns names of the signature algorithms.
+func signatureNames(algs []x509.SignatureAlgorithm) []string {
+	names := make([]string, len(algs))
+	for i, alg := range algs {
+		names[i] = signatureName(alg)
+	}
+	return names
+}
+
+// KeyWarning describes why the public key is weak or returns an empty string
+// if it complies with the policy.
+func (p *CryptoPolicy) KeyWarning(pub any) string {
+	switch pub := pub.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < p.MinRSABits {
+			return fmt.Sprintf("RSA key is shorter than %d bits", p.MinRSABits)
+		}
+	case *ecdsa.PublicKey:
+		if name := pub.Curve.Params().Name; slices.Contains(p.WeakCurves, name) {
+			return fmt.Sprintf("curve %s is deprecated", name)
+		}
+	case *dsa.PublicKey:
+		if !p.AllowDSA {
+			return "DSA is deprecated"
+		}
+	}
+	return ""
+}
+
+// SignatureWarning describes why the signature algorithm is weak or returns an
+// empty string if it complies with the policy.
+func (p *CryptoPolicy) SignatureWarning(alg x509.SignatureAlgorithm) string {
+	if slices.Contains(p.WeakSignatures, alg) {
+		return fmt.Sprintf("%s is insecure", signatureName(alg))
+	}
+	return ""
+}
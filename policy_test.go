@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/dsa"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+func TestCryptoPolicy(t *testing.T) {
+	policy := DefaultCryptoPolicy
+
+	keys := []struct {
+		name string
+		pub  any
+		weak bool
+	}{
+		{"RSA 1024", &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 1023), E: 65537}, true},
+		{"RSA 2048", &rsa.PublicKey{N: new(big.Int).Lsh(big.NewInt(1), 2047), E: 65537}, false},
+		{"P-224", &ecdsa.PublicKey{Curve: elliptic.P224()}, true},
+		{"P-256", &ecdsa.PublicKey{Curve: elliptic.P256()}, false},
+	}
+	for _, k := range keys {
+		if got := policy.KeyWarning(k.pub); (got != "") != k.weak {
+			t.Errorf("KeyWarning(%s) == %q, want weak %t", k.name, got, k.weak)
+		}
+	}
+
+	signatures := []struct {
+		alg  x509.SignatureAlgorithm
+		weak bool
+	}{
+		{x509.SHA1WithRSA, true},
+		{x509.MD5WithRSA, true},
+		{x509.SHA256WithRSA, false},
+		{x509.ECDSAWithSHA384, false},
+	}
+	for _, s := range signatures {
+		if got := policy.SignatureWarning(s.alg); (got != "") != s.weak {
+			t.Errorf("SignatureWarning(%s) == %q, want weak %t", s.alg, got, s.weak)
+		}
+	}
+}
+
+func TestNewCryptoPolicy(t *testing.T) {
+	policy, err := NewCryptoPolicy(3072, []string{"sha256-rsa", "MD2-RSA"}, []string{"p-256"}, true)
+	if err != nil {
+		t.Fatalf("NewCryptoPolicy: %v", err)
+	}
+	if policy.SignatureWarning(x509.SHA256WithRSA) == "" || policy.SignatureWarning(x509.MD2WithRSA) == "" || policy.SignatureWarning(x509.SHA1WithRSA) != "" {
+		t.Errorf("WeakSignatures == %v, want SHA256-RSA and MD2-RSA", policy.WeakSignatures)
+	}
+	if policy.KeyWarning(&ecdsa.PublicKey{Curve: elliptic.P256()}) == "" || policy.KeyWarning(&dsa.PublicKey{}) != "" {
+		t.Errorf("policy %+v doesn't warn about P-256 only", policy)
+	}
+
+	if _, err := NewCryptoPolicy(2048, []string{"SHA3-RSA"}, nil, false); err == nil {
+		t.Errorf("unknown signature algorithm didn't fail")
+	}
+	if _, err := NewCryptoPolicy(2048, nil, []string{"P-192"}, false); err == nil {
+		t.Errorf("unknown curve didn't fail")
+	}
+
+	// The default flags give the default policy, empty values accept all
+	config, err := parseArguments("example.com")
+	if err != nil {
+		t.Fatalf("parse arguments: %v", err)
+	}
+	if !reflect.DeepEqual(config.CryptoPolicy, DefaultCryptoPolicy) {
+		t.Errorf("default policy == %+v, want %+v", config.CryptoPolicy, DefaultCryptoPolicy)
+	}
+	config, err = parseArguments("--weak-signatures=", "--weak-curves=", "example.com")
+	if err != nil {
+		t.Fatalf("parse arguments: %v", err)
+	}
+	if len(config.CryptoPolicy.WeakSignatures) != 0 || len(config.CryptoPolicy.WeakCurves) != 0 {
+		t.Errorf("policy with empty flags == %+v", config.CryptoPolicy)
+	}
+}
@@ -2,18 +2,40 @@ package main
 
 import (
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 )
 
-// Record holds verification results for a single certificate.
+// Record holds verification results for a single certificate or, if Request or
+// CRL is set, for a certificate request or a revocation list.
 type Record struct {
-	Cert   *Certificate
-	Error  error
-	IsRoot bool
+	Cert    *Certificate
+	Request *Request
+	CRL     *RevocationList
+	Error   error
+	IsRoot  bool
+
+	// Source is the file, URL or target the record was loaded from. Records
+	// of sources that failed to load only have Source and Error set.
+	Source string
+
+	// CRLIssuer is the certificate that verified the CRL signature, if any
+	CRLIssuer *Certificate
 
 	Validity Validity
+
+	// Findings are lint rule violations, if linting is enabled
+	Findings []Finding
+
+	// KeyWarning and SignatureWarning describe weak cryptography, if any
+	KeyWarning       string
+	SignatureWarning string
+
+	// CT holds verified SCTs of the leaf, if Certificate Transparency is
+	// checked
+	CT *CTResult
 }
 
 type Validity struct {
@@ -22,10 +44,35 @@ type Validity struct {
 	NotAfterOK  bool
 	Period      Duration
 	ExpiresIn   Duration
+	Status      ValidityStatus
+}
+
+// ValidityStatus tells whether a certificate is valid, about to expire or
+// outside its validity period.
+type ValidityStatus int
+
+const (
+	StatusOK ValidityStatus = iota
+	StatusExpiring
+	// StatusExpired also covers certificates that are not valid yet
+	StatusExpired
+)
+
+func (s ValidityStatus) String() string {
+	switch s {
+	case StatusOK:
+		return "ok"
+	case StatusExpiring:
+		return "expiring"
+	case StatusExpired:
+		return "expired"
+	default:
+		return fmt.Sprintf("ValidityStatus(%d)", int(s))
+	}
 }
 
 func NewRecord(cert *Certificate, err error, opts *VerifyOptions) *Record {
-	return &Record{
+	rec := &Record{
 		Cert:   cert,
 		Error:  err,
 		IsRoot: isRootCert(cert, opts.Roots),
@@ -37,11 +84,89 @@ func NewRecord(cert *Certificate, err error, opts *VerifyOptions) *Record {
 			ExpiresIn:   expiresIn(cert.inner, opts.Time),
 		},
 	}
+
+	switch {
+	case !rec.Validity.OK:
+		rec.Validity.Status = StatusExpired
+	case rec.Validity.ExpiresIn < Duration(opts.WarnWithin):
+		rec.Validity.Status = StatusExpiring
+	}
+
+	rec.Findings = Lint(cert, opts.LintRules)
+	if opts.CryptoPolicy != nil {
+		rec.KeyWarning = opts.CryptoPolicy.KeyWarning(cert.inner.PublicKey)
+		rec.SignatureWarning = opts.CryptoPolicy.SignatureWarning(cert.inner.SignatureAlgorithm)
+	}
+
+	return rec
+}
+
+// NewRequestRecord creates a record for a certificate request. Requests have no
+// chain or validity period, so only the self-signature is checked.
+func NewRequestRecord(req *Request, opts *VerifyOptions) *Record {
+	rec := &Record{
+		Request: req,
+		Error:   req.inner.CheckSignature(),
+	}
+	if opts.CryptoPolicy != nil {
+		rec.KeyWarning = opts.CryptoPolicy.KeyWarning(req.inner.PublicKey)
+		rec.SignatureWarning = opts.CryptoPolicy.SignatureWarning(req.inner.SignatureAlgorithm)
+	}
+	return rec
+}
+
+// NewCRLRecord creates a record for a revocation list. Validity is taken from
+// the this and next update times. The signature is verified if the issuer is
+// found among the supplied CRL issuers, intermediates, roots or bundle.
+func NewCRLRecord(crl *RevocationList, bundle Bundle, opts *VerifyOptions) *Record {
+	inner := crl.inner
+	rec := &Record{
+		CRL: crl,
+		Validity: Validity{
+			NotBeforeOK: opts.Time.After(inner.ThisUpdate),
+			NotAfterOK:  inner.NextUpdate.IsZero() || opts.Time.Before(inner.NextUpdate),
+		},
+	}
+	rec.Validity.OK = rec.Validity.NotBeforeOK && rec.Validity.NotAfterOK
+	if !rec.Validity.OK {
+		rec.Validity.Status = StatusExpired
+	}
+	if !inner.NextUpdate.IsZero() {
+		rec.Validity.Period = Duration(inner.NextUpdate.Sub(inner.ThisUpdate))
+		rec.Validity.ExpiresIn = Duration(inner.NextUpdate.Sub(opts.Time))
+	}
+
+	issuer, err := crl.findIssuer(opts.CRLIssuers, bundle, opts.Intermediates, opts.Roots)
+	switch {
+	case err != nil:
+		rec.Error = fmt.Errorf("verify CRL signature: %w", err)
+	case issuer == nil && len(opts.CRLIssuers) > 0:
+		rec.Error = errors.New("CRL issuer is not among supplied issuer certificates")
+	case !rec.Validity.OK:
+		rec.Error = errors.New("CRL is not within its update period")
+	}
+	rec.CRLIssuer = issuer
+
+	return rec
 }
 
 func (r *Record) String() string {
 	var parts []string
 	parts = append(parts, "Record{")
+	if r.Request != nil || r.CRL != nil {
+		if r.Request != nil {
+			parts = append(parts, fmt.Sprintf("  Request: %s", r.Request.String()))
+		} else {
+			parts = append(parts, fmt.Sprintf("  CRL: %s", r.CRL.String()))
+		}
+		if r.Error != nil {
+			parts = append(parts, fmt.Sprintf("  Error: %v", r.Error))
+		} else {
+			parts = append(parts, "  Error: <nil>")
+		}
+		parts = append(parts, "}")
+		return strings.Join(parts, "\n")
+	}
 	if r.Cert != nil {
 		parts = append(parts, fmt.Sprintf("  Cert: %s", r.Cert.String()))
 	} else {
@@ -54,6 +179,7 @@ func (r *Record) String() string {
 	}
 	parts = append(parts, fmt.Sprintf("  IsRoot: %t", r.IsRoot))
 	parts = append(parts, fmt.Sprintf("  Valid: %t", r.Validity.OK))
+	parts = append(parts, fmt.Sprintf("  Status: %s", r.Validity.Status))
 	parts = append(parts, fmt.Sprintf("  Validity: %s", r.Validity.Period))
 	parts = append(parts, fmt.Sprintf("  ExpiresIn: %s", r.Validity.ExpiresIn))
 	parts = append(parts, "}")
@@ -78,6 +204,18 @@ func (r Report) String() string {
 	return strings.Join(parts, "\n")
 }
 
+// Soonest returns the certificate record that expires first or nil if there
+// are no certificates.
+func (r Report) Soonest() *Record {
+	var soonest *Record
+	for _, rec := range r {
+		if rec.Cert != nil && (soonest == nil || rec.Validity.ExpiresIn < soonest.Validity.ExpiresIn) {
+			soonest = rec
+		}
+	}
+	return soonest
+}
+
 func isRootCert(cert *Certificate, roots Bundle) bool {
 	return false
 }
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+)
+
+// Request wraps an x509.CertificateRequest (CSR).
+type Request struct {
+	inner *x509.CertificateRequest
+}
+
+// NewRequest creates a certificate request from DER-encoded data block
+func NewRequest(data []byte) (*Request, error) {
+	inner, err := x509.ParseCertificateRequest(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse certificate request: %w", err)
+	}
+	return &Request{inner}, nil
+}
+
+// Bytes returns the DER-encoded form of the certificate request.
+func (r *Request) Bytes() []byte {
+	return r.inner.Raw
+}
+
+func (r *Request) String() string {
+	return fmt.Sprintf("Request{Subject: %s, SignatureAlgorithm: %s}",
+		r.inner.Subject.String(),
+		r.inner.SignatureAlgorithm)
+}
+
+var (
+	oidExtKeyUsage         = asn1.ObjectIdentifier{2, 5, 29, 15}
+	oidExtExtendedKeyUsage = asn1.ObjectIdentifier{2, 5, 29, 37}
+)
+
+// extKeyUsageOIDs maps extended key usage OIDs to their x509 constants. The
+// x509 package doesn't parse these for requests, so we have to.
+var extKeyUsageOIDs = []struct {
+	oid   asn1.ObjectIdentifier
+	usage x509.ExtKeyUsage
+}{
+	{asn1.ObjectIdentifier{2, 5, 29, 37, 0}, x509.ExtKeyUsageAny},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 1}, x509.ExtKeyUsageServerAuth},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 2}, x509.ExtKeyUsageClientAuth},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 3}, x509.ExtKeyUsageCodeSigning},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 4}, x509.ExtKeyUsageEmailProtection},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 5}, x509.ExtKeyUsageIPSECEndSystem},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 6}, x509.ExtKeyUsageIPSECTunnel},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 7}, x509.ExtKeyUsageIPSECUser},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 8}, x509.ExtKeyUsageTimeStamping},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 3, 9}, x509.ExtKeyUsageOCSPSigning},
+	{asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 311, 10, 3, 3}, x509.ExtKeyUsageMicrosoftServerGatedCrypto},
+	{asn1.ObjectIdentifier{2, 16, 840, 1, 113730, 4, 1}, x509.ExtKeyUsageNetscapeServerGatedCrypto},
+}
+
+// KeyUsage returns the key usage requested in the CSR extensions.
+func (r *Request) KeyUsage() (x509.KeyUsage, error) {
+	for _, ext := range r.inner.Extensions {
+		if !ext.Id.Equal(oidExtKeyUsage) {
+			continue
+		}
+
+		var bits asn1.BitString
+		if _, err := asn1.Unmarshal(ext.Value, &bits); err != nil {
+			return 0, fmt.Errorf("parse key usage: %w", err)
+		}
+
+		var ku x509.KeyUsage
+		for i := range 9 {
+			if bits.At(i) != 0 {
+				ku |= 1 << uint(i)
+			}
+		}
+		return ku, nil
+	}
+	return 0, nil
+}
+
+// ExtKeyUsage returns the extended key usages requested in the CSR extensions.
+// Usages unknown to the x509 package are returned as OIDs.
+func (r *Request) ExtKeyUsage() ([]x509.ExtKeyUsage, []asn1.ObjectIdentifier, error) {
+	for _, ext := range r.inner.Extensions {
+		if !ext.Id.Equal(oidExtExtendedKeyUsage) {
+			continue
+		}
+
+		var oids []asn1.ObjectIdentifier
+		if _, err := asn1.Unmarshal(ext.Value, &oids); err != nil {
+			return nil, nil, fmt.Errorf("parse extended key usage: %w", err)
+		}
+
+		var (
+			usages  []x509.ExtKeyUsage
+			unknown []asn1.ObjectIdentifier
+		)
+		for _, oid := range oids {
+			if usage, ok := extKeyUsageFromOID(oid); ok {
+				usages = append(usages, usage)
+			} else {
+				unknown = append(unknown, oid)
+			}
+		}
+		return usages, unknown, nil
+	}
+	return nil, nil, nil
+}
+
+func extKeyUsageFromOID(oid asn1.ObjectIdentifier) (x509.ExtKeyUsage, bool) {
+	for _, entry := range extKeyUsageOIDs {
+		if oid.Equal(entry.oid) {
+			return entry.usage, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// certExtensions are file extensions expected to hold certificates, requests
+// or CRLs. When scanning, such files are reported if they can't be parsed while
+// other files are silently skipped.
+var certExtensions = []string{".pem", ".crt", ".cer", ".cert", ".der", ".csr", ".crl"}
+
+// Section holds the report for a single source or a file when scanning
+// multiple files.
+type Section struct {
+	Source string
+	Report Report
+	Error  error
+}
+
+// IsMultiSource reports whether source is a directory or a glob pattern
+// matching some files. Patterns without matches are not considered globs, so
+// URLs with query strings still work.
+func IsMultiSource(source string) bool {
+	if isGlob(source) {
+		matches, err := filepath.Glob(source)
+		return err == nil && len(matches) > 0
+	}
+
+	info, err := os.Stat(source)
+	return err == nil && info.IsDir()
+}
+
+func isGlob(source string) bool {
+	return strings.ContainsAny(source, "*?[")
+}
+
+// ExpandSource returns regular files in a directory, walking it recursively,
+// or files matching a glob pattern. Matched directories are walked as well.
+// Symlinks to files are followed, symlinks to directories are not descended.
+// Files reachable by several paths are returned once. Paths that can't be
+// read are skipped and returned as failed sections.
+func ExpandSource(source string) (files []string, failed []Section, err error) {
+	matches := []string{source}
+	if isGlob(source) {
+		matches, err = filepath.Glob(source)
+		if err != nil {
+			return nil, nil, fmt.Errorf("glob %q: %w", source, err)
+		}
+	}
+
+	seen := make(map[string]bool)
+	add := func(path string) {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			real = path
+		}
+		if !seen[real] {
+			seen[real] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, match := range matches {
+		err := filepath.WalkDir(match, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				failed = append(failed, Section{Source: path, Error: err})
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+
+			// Resolve symlinks, WalkDir doesn't follow them
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil
+			}
+
+			if info.Mode().IsRegular() {
+				add(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, nil, fmt.Errorf("walk %q: %w", match, err)
+		}
+	}
+
+	slices.Sort(files)
+	return files, failed, nil
+}
+
+// Scan loads and verifies every recognizable file in a directory or glob.
+// Files that fail to parse and directories that can't be read are returned as
+// sections with an error.
+func Scan(source string, opts *VerifyOptions) ([]Section, error) {
+	files, sections, err := ExpandSource(source)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		in, err := LoadInput(file)
+		if err != nil {
+			if hasCertExtension(file) {
+				sections = append(sections, Section{Source: file, Error: err})
+			}
+			continue
+		}
+
+		if len(in.Bundle) == 0 && len(in.Requests) == 0 && len(in.CRLs) == 0 {
+			continue
+		}
+
+		report, err := VerifyInput(in, opts)
+		if err != nil {
+			sections = append(sections, Section{Source: file, Error: err})
+			continue
+		}
+
+		sections = append(sections, Section{Source: file, Report: report})
+	}
+
+	return sections, nil
+}
+
+// CheckSources loads and verifies every source, expanding directories and
+// globs. Plain sources, such as URLs, are checked concurrently by at most
+// parallel workers. Sections are returned in the order of sources.
+func CheckSources(sources []string, opts *VerifyOptions, parallel int) []Section {
+	results := make([][]Section, len(sources))
+	scanned := make([]bool, len(sources))
+	for i, source := range sources {
+		if IsMultiSource(source) {
+			sections, err := Scan(source, opts)
+			if err != nil {
+				sections = []Section{{Source: source, Error: err}}
+			}
+			results[i] = sections
+			scanned[i] = true
+		}
+	}
+
+	forEachParallel(len(sources), parallel, func(i int) {
+		if !scanned[i] {
+			results[i] = []Section{checkSource(sources[i], opts)}
+		}
+	})
+
+	return slices.Concat(results...)
+}
+
+// forEachParallel calls fn for every index in [0, n) using at most parallel
+// goroutines and waits for all of them to finish.
+func forEachParallel(n, parallel int, fn func(i int)) {
+	sem := make(chan struct{}, max(parallel, 1))
+
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
+
+func checkSource(source string, opts *VerifyOptions) Section {
+	in, err := LoadInput(source)
+	if err != nil {
+		return Section{Source: source, Error: err}
+	}
+
+	report, err := VerifyInput(in, opts)
+	if err != nil {
+		return Section{Source: source, Error: err}
+	}
+
+	return Section{Source: source, Report: report}
+}
+
+func hasCertExtension(path string) bool {
+	return slices.Contains(certExtensions, strings.ToLower(filepath.Ext(path)))
+}
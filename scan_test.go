@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+
+	raw, err := os.ReadFile(filepath.Join("testdata", "example.com.crt"))
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	files := map[string][]byte{
+		"a/example.com.crt": raw,
+		"b/fullchain.pem":   raw,
+		"b/broken.pem":      []byte("not a certificate"),
+		"README":            []byte("not a certificate either"),
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	if !IsMultiSource(dir) {
+		t.Fatalf("IsMultiSource(%q) == false, want true", dir)
+	}
+
+	sections, err := Scan(dir, &VerifyOptions{
+		Time: time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+
+	inv := NewInventory(sections)
+	if inv.Sources != 3 {
+		t.Errorf("Sources == %d, want 3", inv.Sources)
+	}
+	if inv.Certificates != 6 {
+		t.Errorf("Certificates == %d, want 6", inv.Certificates)
+	}
+	if len(inv.Duplicates) != 3 {
+		t.Errorf("len(Duplicates) == %d, want 3", len(inv.Duplicates))
+	}
+	if len(inv.Failed) != 1 || inv.Failed[0].Source != filepath.Join(dir, "b/broken.pem") {
+		t.Errorf("Failed == %v, want b/broken.pem", inv.Failed)
+	}
+	if inv.Soonest == nil || inv.Soonest.Record.Cert.inner.Subject.CommonName != "example.com" {
+		t.Errorf("Soonest == %v, want example.com", inv.Soonest)
+	}
+
+	// Glob matches only the .crt file
+	sections, err = Scan(filepath.Join(dir, "*", "*.crt"), &VerifyOptions{})
+	if err != nil {
+		t.Fatalf("scan glob: %v", err)
+	}
+	if len(sections) != 1 {
+		t.Errorf("glob sections == %d, want 1", len(sections))
+	}
+}
+
+func TestScanUnreadableDir(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ca.crt"), mustReadFile(t, "testdata/ca.crt"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	private := filepath.Join(dir, "private")
+	if err := os.Mkdir(private, 0); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	defer os.Chmod(private, 0755)
+
+	sections, err := Scan(dir, &VerifyOptions{})
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	inv := NewInventory(sections)
+	if inv.Certificates != 1 {
+		t.Errorf("Certificates == %d, want 1", inv.Certificates)
+	}
+	if len(inv.Failed) != 1 || inv.Failed[0].Source != private {
+		t.Errorf("Failed == %v, want %s", inv.Failed, private)
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return data
+}
+
+func TestCheckSources(t *testing.T) {
+	sources := []string{
+		filepath.Join("testdata", "example.com.crt"),
+		"examplecom\\",
+		filepath.Join("testdata", "example.com.csr"),
+	}
+
+	sections := CheckSources(sources, &VerifyOptions{
+		Time: time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC),
+	}, 2)
+	if len(sections) != len(sources) {
+		t.Fatalf("len(sections) == %d, want %d", len(sections), len(sources))
+	}
+
+	for i, s := range sections {
+		if s.Source != sources[i] {
+			t.Errorf("sections[%d].Source == %q, want %q", i, s.Source, sources[i])
+		}
+	}
+
+	if sections[0].Error != nil || len(sections[0].Report) != 3 {
+		t.Errorf("sections[0] == %v, %d records, want 3 records", sections[0].Error, len(sections[0].Report))
+	}
+	if sections[1].Error == nil {
+		t.Errorf("sections[1] expected error for invalid source")
+	}
+}
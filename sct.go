@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+var (
+	oidExtSCTList     = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+	oidOCSPExtSCTList = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 5}
+)
+
+// Sources of SCTs.
+const (
+	SCTSourceCertificate = "certificate"
+	SCTSourceTLS         = "TLS"
+	SCTSourceOCSP        = "OCSP"
+)
+
+// SCT is a Certificate Transparency signed certificate timestamp, see RFC 6962
+// section 3.2.
+type SCT struct {
+	Version    uint8
+	LogID      [32]byte
+	Timestamp  time.Time
+	Extensions []byte
+
+	HashAlgorithm      uint8
+	SignatureAlgorithm uint8
+	Signature          []byte
+
+	// Source tells how the SCT was delivered
+	Source string
+
+	// raw timestamp in milliseconds as signed by the log
+	timestamp uint64
+}
+
+// Algorithm returns the name of the SCT signature algorithm.
+func (s *SCT) Algorithm() string {
+	hash := fmt.Sprintf("Hash(%d)", s.HashAlgorithm)
+	if s.HashAlgorithm == 4 {
+		hash = "SHA256"
+	}
+
+	switch s.SignatureAlgorithm {
+	case 1:
+		return "RSA-" + hash
+	case 3:
+		return "ECDSA-" + hash
+	default:
+		return fmt.Sprintf("Signature(%d)-%s", s.SignatureAlgorithm, hash)
+	}
+}
+
+// embeddedSCTs parses SCTs from the certificate extension. It returns nil if
+// the certificate has no SCTs.
+func embeddedSCTs(cert *x509.Certificate) ([]SCT, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidExtSCTList) {
+			continue
+		}
+
+		var list []byte
+		if _, err := asn1.Unmarshal(ext.Value, &list); err != nil {
+			return nil, fmt.Errorf("unmarshal SCT extension: %w", err)
+		}
+		return parseSCTList(list, SCTSourceCertificate)
+	}
+	return nil, nil
+}
+
+// stapledSCTs parses SCTs from the single response extension of an OCSP
+// response.
+func stapledSCTs(raw []byte) ([]SCT, error) {
+	resp, err := ocsp.ParseResponse(raw, nil)
+	if err != nil {
+		return nil, fmt.Errorf("parse OCSP response: %w", err)
+	}
+
+	for _, ext := range resp.Extensions {
+		if !ext.Id.Equal(oidOCSPExtSCTList) {
+			continue
+		}
+
+		var list []byte
+		if _, err := asn1.Unmarshal(ext.Value, &list); err != nil {
+			return nil, fmt.Errorf("unmarshal OCSP SCT extension: %w", err)
+		}
+		return parseSCTList(list, SCTSourceOCSP)
+	}
+	return nil, nil
+}
+
+var errSCTTruncated = errors.New("SCT list is truncated")
+
+// parseSCTList parses a TLS encoded SignedCertificateTimestampList delivered
+// from the source.
+func parseSCTList(data []byte, source string) ([]SCT, error) {
+	list, rest, ok := readVector16(data)
+	if !ok || len(rest) != 0 {
+		return nil, errSCTTruncated
+	}
+
+	var scts []SCT
+	for len(list) > 0 {
+		var raw []byte
+		raw, list, ok = readVector16(list)
+		if !ok {
+			return nil, errSCTTruncated
+		}
+
+		sct, err := parseSCT(raw)
+		if err != nil {
+			return nil, err
+		}
+		sct.Source = source
+		scts = append(scts, sct)
+	}
+	return scts, nil
+}
+
+func parseSCT(data []byte) (SCT, error) {
+	var sct SCT
+
+	// version, log ID and timestamp
+	if len(data) < 1+32+8 {
+		return sct, errSCTTruncated
+	}
+	sct.Version = data[0]
+	if sct.Version != 0 {
+		return sct, fmt.Errorf("unsupported SCT version %d", sct.Version)
+	}
+	copy(sct.LogID[:], data[1:33])
+	sct.timestamp = binary.BigEndian.Uint64(data[33:41])
+	sct.Timestamp = time.UnixMilli(int64(sct.timestamp)).UTC()
+
+	var ok bool
+	sct.Extensions, data, ok = readVector16(data[41:])
+	if !ok || len(data) < 2 {
+		return sct, errSCTTruncated
+	}
+
+	sct.HashAlgorithm, sct.SignatureAlgorithm = data[0], data[1]
+	sct.Signature, data, ok = readVector16(data[2:])
+	if !ok || len(data) != 0 {
+		return sct, errSCTTruncated
+	}
+
+	return sct, nil
+}
+
+// readVector16 reads a TLS vector with a 16-bit length prefix.
+func readVector16(data []byte) (vec, rest []byte, ok bool) {
+	if len(data) < 2 {
+		return nil, nil, false
+	}
+	n := int(binary.BigEndian.Uint16(data))
+	if len(data) < 2+n {
+		return nil, nil, false
+	}
+	return data[2 : 2+n], data[2+n:], true
+}
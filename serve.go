@@ -0,0 +1,264 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Exporter serves certificate metrics in the Prometheus text format.
+type Exporter struct {
+	// Targets are probed on every /metrics scrape
+	Targets []Target
+
+	// Options are used to verify probed certificates. Time is set for each
+	// probe.
+	Options VerifyOptions
+
+	// Parallel limits concurrent probes on /metrics
+	Parallel int
+}
+
+// probeResult is the result of checking a single target.
+type probeResult struct {
+	Target   Target
+	Section  Section
+	Duration time.Duration
+}
+
+func (e *Exporter) probe(t Target) probeResult {
+	start := time.Now()
+
+	opts := e.Options
+	opts.Time = start
+
+	sections := CheckTargets([]Target{t}, &opts, 1)
+	return probeResult{
+		Target:   t,
+		Section:  sections[0],
+		Duration: time.Since(start),
+	}
+}
+
+// Handler returns the HTTP handler with /metrics and /probe endpoints.
+func (e *Exporter) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", e.handleMetrics)
+	mux.HandleFunc("/probe", e.handleProbe)
+	return mux
+}
+
+func (e *Exporter) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	probes := make([]probeResult, len(e.Targets))
+	forEachParallel(len(e.Targets), e.Parallel, func(i int) {
+		probes[i] = e.probe(e.Targets[i])
+	})
+
+	writeMetricsResponse(w, probes)
+}
+
+// handleProbe checks the target from the query, like blackbox exporter does.
+func (e *Exporter) handleProbe(w http.ResponseWriter, r *http.Request) {
+	addr := r.URL.Query().Get("target")
+	if addr == "" {
+		http.Error(w, "target parameter is missing", http.StatusBadRequest)
+		return
+	}
+
+	t := Target{Addr: addr, ServerName: r.URL.Query().Get("servername")}
+	writeMetricsResponse(w, []probeResult{e.probe(t)})
+}
+
+func writeMetricsResponse(w http.ResponseWriter, probes []probeResult) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := writeMetrics(w, probes); err != nil {
+		log.Printf("failed to write metrics: %v", err)
+	}
+}
+
+// metric describes a gauge and how to get its samples from a probe.
+type metric struct {
+	name    string
+	help    string
+	samples func(p probeResult) []sample
+}
+
+type sample struct {
+	labels [][2]string
+	value  float64
+}
+
+var metrics = []metric{
+	{
+		name: "cert_probe_success",
+		help: "Whether certificates were fetched from the target.",
+		samples: func(p probeResult) []sample {
+			return []sample{{value: boolValue(p.Section.Error == nil)}}
+		},
+	},
+	{
+		name: "cert_probe_duration_seconds",
+		help: "Duration of the probe in seconds.",
+		samples: func(p probeResult) []sample {
+			return []sample{{value: p.Duration.Seconds()}}
+		},
+	},
+	{
+		name: "cert_chain_verified",
+		help: "Whether the certificate chain was verified.",
+		samples: func(p probeResult) []sample {
+			if p.Section.Error != nil {
+				return nil
+			}
+			return []sample{{value: boolValue(p.Section.Report.ExitCode() != ExitVerifyFailed)}}
+		},
+	},
+	{
+		name: "cert_not_after_timestamp_seconds",
+		help: "Earliest NotAfter of the certificates in the chain as a Unix timestamp.",
+		samples: func(p probeResult) []sample {
+			if rec := p.Section.Report.Soonest(); rec != nil {
+				return []sample{{value: float64(rec.Cert.inner.NotAfter.Unix())}}
+			}
+			return nil
+		},
+	},
+	{
+		name: "cert_expiry_seconds",
+		help: "Seconds until the earliest certificate in the chain expires.",
+		samples: func(p probeResult) []sample {
+			if rec := p.Section.Report.Soonest(); rec != nil {
+				return []sample{{value: time.Duration(rec.Validity.ExpiresIn).Seconds()}}
+			}
+			return nil
+		},
+	},
+	{
+		name: "cert_info",
+		help: "Certificates in the chain by position, 0 being the leaf.",
+		samples: func(p probeResult) []sample {
+			var samples []sample
+			for i, rec := range p.Section.Report {
+				if rec.Cert == nil {
+					continue
+				}
+				c := rec.Cert.inner
+				samples = append(samples, sample{
+					labels: [][2]string{
+						{"position", fmt.Sprint(i)},
+						{"subject", c.Subject.String()},
+						{"issuer", c.Issuer.String()},
+						{"serial", fmt.Sprintf("%X", c.SerialNumber)},
+						{"fingerprint", fmt.Sprintf("%X", rec.Cert.fingerprint)},
+					},
+					value: 1,
+				})
+			}
+			return samples
+		},
+	},
+}
+
+// writeMetrics writes gauges for all probes in the Prometheus text format.
+func writeMetrics(w io.Writer, probes []probeResult) error {
+	var b strings.Builder
+	for _, m := range metrics {
+		fmt.Fprintf(&b, "# HELP %s %s\n", m.name, m.help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", m.name)
+		for _, p := range probes {
+			for _, s := range m.samples(p) {
+				labels := [][2]string{{"target", p.Target.Addr}}
+				if p.Target.ServerName != "" {
+					labels = append(labels, [2]string{"servername", p.Target.ServerName})
+				}
+				labels = append(labels, s.labels...)
+				fmt.Fprintf(&b, "%s{%s} %s\n", m.name, formatLabels(labels), strconv.FormatFloat(s.value, 'f', -1, 64))
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func formatLabels(labels [][2]string) string {
+	escaper := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = fmt.Sprintf(`%s="%s"`, l[0], escaper.Replace(l[1]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// runServe implements the serve subcommand.
+func runServe(args []string) int {
+	flags := pflag.NewFlagSet("serve", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s serve [options]\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nServe certificate metrics for Prometheus on /metrics and /probe?target=<host>.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+
+	listen := flags.String("listen", ":9219", "Address to listen on.")
+	targetsPath := flags.String("targets", "", "Path to a file with targets probed on /metrics, same format as for --targets.")
+	rootsPath := flags.StringSliceP("roots", "r", nil, "Path to custom roots. Can be specified multiple times.")
+	intermediatesPath := flags.StringSliceP("intermediates", "i", nil, "Paths to intermediates. Can be specified multiple times.")
+	parallel := flags.Int("parallel", 10, "Number of targets probed concurrently.")
+	flags.DurationVar(&Timeout, "timeout", Timeout, "Timeout for each TLS connection.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		// ContinueOnError doesn't print parse errors
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	exporter := &Exporter{Parallel: *parallel}
+
+	var err error
+	if *targetsPath != "" {
+		exporter.Targets, err = LoadTargets(*targetsPath)
+		if err != nil {
+			log.Printf("failed to load targets: %v", err)
+			return ExitLoadFailed
+		}
+	}
+
+	exporter.Options.Roots, err = LoadMulti(*rootsPath)
+	if err != nil {
+		log.Printf("failed to load roots: %v", err)
+		return ExitLoadFailed
+	}
+
+	exporter.Options.Intermediates, err = LoadMulti(*intermediatesPath)
+	if err != nil {
+		log.Printf("failed to load intermediates: %v", err)
+		return ExitLoadFailed
+	}
+
+	log.Printf("listening on %s with %d targets", *listen, len(exporter.Targets))
+	if err := http.ListenAndServe(*listen, exporter.Handler()); err != nil {
+		log.Printf("failed to serve: %v", err)
+		return ExitLoadFailed
+	}
+	return ExitOK
+}
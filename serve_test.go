@@ -0,0 +1,62 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestExporter(t *testing.T) {
+	backend := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer backend.Close()
+
+	root, err := NewCertificateFromX509(backend.Certificate())
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+
+	exporter := &Exporter{
+		Targets:  []Target{{Addr: backend.URL, ServerName: "example.com"}},
+		Options:  VerifyOptions{Roots: Bundle{root}},
+		Parallel: 1,
+	}
+
+	srv := httptest.NewServer(exporter.Handler())
+	defer srv.Close()
+
+	get := func(path string) string {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		defer resp.Body.Close()
+
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("read %s: %v", path, err)
+		}
+		return string(body)
+	}
+
+	metrics := get("/metrics")
+	for _, want := range []string{
+		`cert_probe_success{target="` + backend.URL + `",servername="example.com"} 1`,
+		`cert_chain_verified{target="` + backend.URL + `",servername="example.com"} 1`,
+		`# TYPE cert_expiry_seconds gauge`,
+		`cert_info{target="` + backend.URL + `",servername="example.com",position="0",subject="O=Acme Co"`,
+	} {
+		if !strings.Contains(metrics, want) {
+			t.Errorf("/metrics doesn't contain %q:\n%s", want, metrics)
+		}
+	}
+
+	// The test certificate is not valid for localhost
+	target := strings.Replace(backend.URL, "127.0.0.1", "localhost", 1)
+	probe := get("/probe?target=" + url.QueryEscape(target))
+	if want := `cert_chain_verified{target="` + target + `"} 0`; !strings.Contains(probe, want) {
+		t.Errorf("/probe doesn't contain %q:\n%s", want, probe)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+)
+
+// Profile is a set of certificate options applied when signing a request.
+type Profile struct {
+	Validity    time.Duration
+	KeyUsage    x509.KeyUsage
+	ExtKeyUsage []x509.ExtKeyUsage
+	IsCA        bool
+
+	// NeedsNames requires at least one SAN for the certificate
+	NeedsNames bool
+}
+
+// Profiles maps profile names to their options.
+var Profiles = map[string]Profile{
+	"server": {
+		Validity:    defaultLeafValidity,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		NeedsNames:  true,
+	},
+	"client": {
+		Validity:    defaultLeafValidity,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	},
+	"intermediate": {
+		Validity: 5 * 365 * 24 * time.Hour,
+		IsCA:     true,
+	},
+}
+
+// SignRequest issues a certificate for the request after checking its
+// signature. The subject and the SANs are copied from the request unless names
+// are given. Usages come from the profile, the requested ones are ignored.
+func SignRequest(req *Request, profile Profile, names []string, issuer *Certificate, issuerKey crypto.Signer) (*Certificate, error) {
+	if err := req.inner.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid request signature: %w", err)
+	}
+	if !issuer.inner.IsCA {
+		return nil, errors.New("issuer is not a CA")
+	}
+
+	if len(names) == 0 {
+		names = requestSANs(req.inner).names()
+	}
+	if profile.NeedsNames && len(names) == 0 {
+		return nil, errors.New("request has no SANs, set them with --san")
+	}
+
+	opts := CertOptions{
+		Subject:     req.inner.Subject,
+		Names:       names,
+		Validity:    profile.Validity,
+		KeyUsage:    profile.KeyUsage,
+		ExtKeyUsage: profile.ExtKeyUsage,
+		IsCA:        profile.IsCA,
+	}
+	tmpl, err := opts.Template(req.inner.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	// Keep the subject as requested even if it has no common name
+	tmpl.Subject = req.inner.Subject
+
+	return IssueCertificate(tmpl, req.inner.PublicKey, issuer, issuerKey)
+}
+
+// runSign implements the sign subcommand.
+func runSign(args []string) int {
+	flags := pflag.NewFlagSet("sign", pflag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s sign --ca <ca.pem> --ca-key <ca.key> [options] <request.csr>\n", os.Args[0])
+		fmt.Fprintf(flags.Output(), "\nIssue a certificate for a certificate signing request.\n")
+		fmt.Fprintf(flags.Output(), "\nOptions:\n")
+		flags.PrintDefaults()
+	}
+
+	caFlag := flags.String("ca", "", "Path to the CA certificate, optionally followed by its chain.")
+	caKeyFlag := flags.String("ca-key", "", "Path to the CA private key.")
+	profileFlag := flags.String("profile", "server", "Certificate profile - "+strings.Join(sortedKeys(Profiles), ", ")+".")
+	validityFlag := FlagSetDurationP(flags, "validity", "", 0, "Validity period. Defaults to 90 days for leaves and 5 years for intermediates.")
+	sanFlag := flags.StringSlice("san", nil, "SANs replacing the requested ones, e.g. example.com,127.0.0.1.")
+	certOutFlag := flags.String("cert-out", "", "Path to write the certificate to. Defaults to <common name>.crt.")
+	chainOutFlag := flags.String("chain-out", "", "Path to write the certificate with its chain to. Defaults to <common name>.chain.crt.")
+	forceFlag := flags.Bool("force", false, "Overwrite existing files.")
+	verbosityFlag := flags.CountP("verbose", "v", "Increase output verbosity. Can be specified multiple times.")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, pflag.ErrHelp) {
+			return ExitOK
+		}
+		log.Print(err)
+		flags.Usage()
+		return ExitUsage
+	}
+
+	if flags.NArg() != 1 || *caFlag == "" || *caKeyFlag == "" {
+		flags.Usage()
+		return ExitUsage
+	}
+
+	profile, ok := Profiles[*profileFlag]
+	if !ok {
+		log.Printf("unknown profile %q (valid: %s)", *profileFlag, strings.Join(sortedKeys(Profiles), ", "))
+		return ExitUsage
+	}
+	if *validityFlag > 0 {
+		profile.Validity = *validityFlag
+	}
+
+	verbosity, err := NewOutputLevel(*verbosityFlag)
+	if err != nil {
+		log.Print(err)
+		return ExitUsage
+	}
+
+	in, err := LoadInput(flags.Arg(0))
+	if err != nil {
+		log.Printf("failed to load request: %v", err)
+		return ExitLoadFailed
+	}
+	if len(in.Requests) == 0 {
+		log.Printf("no certificate request found in %s", flags.Arg(0))
+		return ExitLoadFailed
+	}
+
+	issuerChain, err := Load(*caFlag)
+	if err != nil || len(issuerChain) == 0 {
+		log.Printf("failed to load CA: %v", err)
+		return ExitLoadFailed
+	}
+	issuerKey, err := LoadPrivateKey(*caKeyFlag)
+	if err != nil {
+		log.Printf("failed to load CA key: %v", err)
+		return ExitLoadFailed
+	}
+
+	cert, err := SignRequest(in.Requests[0], profile, *sanFlag, issuerChain[0], issuerKey)
+	if err != nil {
+		log.Printf("failed to sign request: %v", err)
+		return ExitVerifyFailed
+	}
+
+	name := fileName(cert)
+	certOut, chainOut := *certOutFlag, *chainOutFlag
+	if certOut == "" {
+		certOut = name + ".crt"
+	}
+	if chainOut == "" {
+		chainOut = name + ".chain.crt"
+	}
+
+	chain := append(Bundle{cert}, issuerChain...)
+	if err := writeFiles(*forceFlag,
+		outputFile{certOut, encodePEMBundle(Bundle{cert}), 0o644},
+		outputFile{chainOut, encodePEMBundle(chain), 0o644},
+	); err != nil {
+		log.Printf("failed to write certificate: %v", err)
+		return ExitLoadFailed
+	}
+	log.Printf("wrote %s and %s", certOut, chainOut)
+
+	return printIssued(cert, issuerChain, verbosity)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestSignRequest(t *testing.T) {
+	caKey, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	caOpts := CertOptions{Subject: pkix.Name{CommonName: "Test CA"}, Validity: time.Hour, IsCA: true, MaxPathLen: -1}
+	caTmpl, err := caOpts.Template(caKey.Public())
+	if err != nil {
+		t.Fatalf("template: %v", err)
+	}
+	ca, err := IssueCertificate(caTmpl, caKey.Public(), nil, caKey)
+	if err != nil {
+		t.Fatalf("issue CA: %v", err)
+	}
+
+	key, err := GenerateKey(KeyECDSA, 0)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: "example.com", Organization: []string{"Example"}},
+		DNSNames:    []string{"example.com", "www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.1")},
+	}, key)
+	if err != nil {
+		t.Fatalf("create request: %v", err)
+	}
+	req, err := NewRequest(der)
+	if err != nil {
+		t.Fatalf("parse request: %v", err)
+	}
+
+	tests := []struct {
+		profile  string
+		names    []string
+		wantDNS  []string
+		wantEKU  []x509.ExtKeyUsage
+		wantIsCA bool
+	}{
+		{"server", nil, []string{"example.com", "www.example.com"}, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}, false},
+		{"client", []string{"client.example.com"}, []string{"client.example.com"}, []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}, false},
+		{"intermediate", nil, []string{"example.com", "www.example.com"}, nil, true},
+	}
+	for _, tt := range tests {
+		cert, err := SignRequest(req, Profiles[tt.profile], tt.names, ca, caKey)
+		if err != nil {
+			t.Fatalf("%s: sign: %v", tt.profile, err)
+		}
+
+		c := cert.inner
+		if c.Subject.String() != req.inner.Subject.String() {
+			t.Errorf("%s: subject == %q, want %q", tt.profile, c.Subject, req.inner.Subject)
+		}
+		if !slices.Equal(c.DNSNames, tt.wantDNS) {
+			t.Errorf("%s: DNSNames == %v, want %v", tt.profile, c.DNSNames, tt.wantDNS)
+		}
+		if !slices.Equal(c.ExtKeyUsage, tt.wantEKU) {
+			t.Errorf("%s: ExtKeyUsage == %v, want %v", tt.profile, c.ExtKeyUsage, tt.wantEKU)
+		}
+		if c.IsCA != tt.wantIsCA {
+			t.Errorf("%s: IsCA == %v, want %v", tt.profile, c.IsCA, tt.wantIsCA)
+		}
+
+		report, err := Verify(Bundle{cert, ca}, &VerifyOptions{Time: time.Now(), Roots: Bundle{ca}, KeyUsages: c.ExtKeyUsage})
+		if err != nil {
+			t.Fatalf("%s: verify: %v", tt.profile, err)
+		}
+		if got := report.ExitCode(); got != ExitOK {
+			t.Errorf("%s: ExitCode == %d, want %d", tt.profile, got, ExitOK)
+		}
+	}
+
+	// Tamper with the signature
+	tampered := slices.Clone(der)
+	tampered[len(tampered)-1] ^= 0xff
+	if bad, err := NewRequest(tampered); err == nil {
+		if _, err := SignRequest(bad, Profiles["server"], nil, ca, caKey); err == nil {
+			t.Errorf("request with invalid signature was signed")
+		}
+	}
+
+	// Only CAs can sign
+	if _, err := SignRequest(req, Profiles["server"], nil, &Certificate{inner: &x509.Certificate{}}, key); err == nil {
+		t.Errorf("request was signed by a non-CA")
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Target is a TLS endpoint to fetch certificates from.
+type Target struct {
+	// Addr is a URL or host[:port]
+	Addr string
+
+	// ServerName overrides the name sent in SNI and checked against the leaf
+	ServerName string
+}
+
+func (t Target) String() string {
+	if t.ServerName == "" {
+		return t.Addr
+	}
+	return t.Addr + " (" + t.ServerName + ")"
+}
+
+// LoadTargets reads targets from a file, see ParseTargets for the format.
+func LoadTargets(path string) ([]Target, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open %q: %w", path, err)
+	}
+	defer f.Close()
+
+	return ParseTargets(f)
+}
+
+// ParseTargets reads one target per line: a URL or host[:port] optionally
+// followed by a server name. Empty lines and lines starting with "#" are
+// ignored.
+func ParseTargets(r io.Reader) ([]Target, error) {
+	var targets []Target
+
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch len(fields) {
+		case 1:
+			targets = append(targets, Target{Addr: fields[0]})
+		case 2:
+			targets = append(targets, Target{Addr: fields[0], ServerName: fields[1]})
+		default:
+			return nil, fmt.Errorf("line %d: expected address and optional server name, got %q", n, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// CheckTargets fetches and verifies certificates from every target using at
+// most parallel workers. Sections are returned in the order of targets.
+func CheckTargets(targets []Target, opts *VerifyOptions, parallel int) []Section {
+	sections := make([]Section, len(targets))
+	forEachParallel(len(targets), parallel, func(i int) {
+		t := targets[i]
+		sections[i] = Section{Source: t.String()}
+
+		in, err := fromURL(t.Addr, t.ServerName)
+		if err != nil {
+			sections[i].Error = err
+			return
+		}
+
+		sections[i].Report, sections[i].Error = VerifyInput(in, opts)
+	})
+	return sections
+}
+
+// FormatSummary prints a table with a line per section describing its leaf
+// certificate.
+func (f *TextFormatter) FormatSummary(out io.Writer, sections []Section) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintf(w, "HOST\tCN\tEXPIRES IN\tSTATUS\tERROR\n")
+	for _, sec := range sections {
+		var (
+			name, expires string
+			err           = sec.Error
+		)
+
+		if len(sec.Report) > 0 && sec.Report[0].Cert != nil {
+			leaf := sec.Report[0]
+			name = displayName(leaf.Cert.inner.Subject)
+			expires = leaf.Validity.ExpiresIn.String()
+			if leaf.Validity.ExpiresIn < 0 {
+				expires = "expired"
+			}
+		}
+
+		// Report the first failing record, usually the leaf
+		for _, rec := range sec.Report {
+			if err == nil && rec.Error != nil {
+				err = rec.Error
+			}
+		}
+
+		status := printBool(err == nil)
+		if err == nil && sec.Report.ExitCode() == ExitExpiring {
+			status = printStatus(StatusExpiring)
+		}
+
+		errText := ""
+		if err != nil {
+			errText = err.Error()
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", sec.Source, name, expires, status, errText)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("tabwriter failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/csv"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseTargets(t *testing.T) {
+	input := `
+# production
+example.com
+https://example.org:8443/health
+10.0.0.1:443 www.example.net
+`
+	want := []Target{
+		{Addr: "example.com"},
+		{Addr: "https://example.org:8443/health"},
+		{Addr: "10.0.0.1:443", ServerName: "www.example.net"},
+	}
+
+	got, err := ParseTargets(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseTargets: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseTargets == %v, want %v", got, want)
+	}
+
+	if _, err := ParseTargets(strings.NewReader("a b c\n")); err == nil {
+		t.Errorf("ParseTargets expected error for extra fields")
+	}
+}
+
+func TestCheckTargets(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	root, err := NewCertificateFromX509(srv.Certificate())
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+
+	targets := []Target{
+		{Addr: srv.URL, ServerName: "example.com"},
+		{Addr: srv.URL, ServerName: "wrong.example.net"},
+	}
+
+	sections := CheckTargets(targets, &VerifyOptions{
+		Time:  time.Now(),
+		Roots: Bundle{root},
+	}, 2)
+
+	for i, s := range sections {
+		if s.Error != nil {
+			t.Fatalf("sections[%d].Error == %v", i, s.Error)
+		}
+		if len(s.Report) != 1 {
+			t.Fatalf("sections[%d] has %d records, want 1", i, len(s.Report))
+		}
+	}
+
+	if err := sections[0].Report[0].Error; err != nil {
+		t.Errorf("example.com: unexpected error %v", err)
+	}
+	if err := sections[1].Report[0].Error; err == nil {
+		t.Errorf("wrong.example.net: expected hostname mismatch")
+	}
+
+	var b strings.Builder
+	if err := (&TextFormatter{}).FormatSummary(&b, sections); err != nil {
+		t.Fatalf("FormatSummary: %v", err)
+	}
+	summary := b.String()
+	if lines := strings.Split(strings.TrimSpace(summary), "\n"); len(lines) != 3 {
+		t.Errorf("summary has %d lines, want 3:\n%s", len(lines), summary)
+	}
+}
+
+func TestTargetsFields(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	root, err := NewCertificateFromX509(srv.Certificate())
+	if err != nil {
+		t.Fatalf("certificate: %v", err)
+	}
+
+	// Nothing listens on the port once the listener is closed
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	unreachable := l.Addr().String()
+	l.Close()
+
+	path := filepath.Join(t.TempDir(), "targets.txt")
+	if err := os.WriteFile(path, []byte(srv.URL+" example.com\n"+unreachable+"\n"), 0o644); err != nil {
+		t.Fatalf("write targets: %v", err)
+	}
+	config, err := parseArguments("--targets", path, "-o", "source,common_name,error", "-f", "csv")
+	if err != nil {
+		t.Fatalf("parse arguments: %v", err)
+	}
+
+	var b strings.Builder
+	code, err := runSources(config, &VerifyOptions{Time: time.Now(), Roots: Bundle{root}}, &b)
+	if err != nil {
+		t.Fatalf("runSources: %v", err)
+	}
+	if code != ExitLoadFailed {
+		t.Errorf("exit code == %d, want %d", code, ExitLoadFailed)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("read output: %v\n%s", err, b.String())
+	}
+	if len(rows) != 2 {
+		t.Fatalf("output has %d rows, want 2:\n%s", len(rows), b.String())
+	}
+	if want := (Target{Addr: srv.URL, ServerName: "example.com"}).String(); rows[0][0] != want || rows[0][2] != "" {
+		t.Errorf("reachable target row == %q", rows[0])
+	}
+	if rows[1][0] != unreachable || !strings.Contains(rows[1][2], "connection refused") {
+		t.Errorf("unreachable target row == %q, want its source and error", rows[1])
+	}
+}
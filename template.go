@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// reportTemplate is the name of an optional template executed once with the
+// whole report instead of once per record.
+const reportTemplate = "report"
+
+// TemplateFormatter writes records with a Go text/template, like docker
+// inspect --format. The template is executed for each record followed by a
+// newline, unless it defines a "report" template which then gets all the
+// records at once and is ended with a newline if needed. Durations such as
+// .Validity.ExpiresIn print like in the text output, the duration and days
+// helpers format them explicitly.
+type TemplateFormatter struct {
+	tmpl *template.Template
+}
+
+// TemplateRecord is what templates are executed against. Certificate fields
+// are copied from the certificate, the request or the revocation list of the
+// record.
+type TemplateRecord struct {
+	*Record
+
+	Subject      pkix.Name
+	Issuer       pkix.Name
+	SerialNumber *big.Int
+	NotBefore    time.Time
+	NotAfter     time.Time
+
+	// Names are SANs: DNS names, IP addresses, emails and URIs
+	Names []string
+
+	// Fingerprint is the SHA-256 hash of the certificate, zero for requests
+	// and revocation lists
+	Fingerprint Fingerprint
+
+	// X509 is the parsed certificate, nil for requests and revocation lists
+	X509 *x509.Certificate
+}
+
+// NewTemplateFormatter parses the template text.
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New("record").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return &TemplateFormatter{tmpl: tmpl}, nil
+}
+
+func (f *TemplateFormatter) Format(w io.Writer, report Report) error {
+	records := make([]*TemplateRecord, 0, len(report))
+	for _, rec := range report {
+		records = append(records, newTemplateRecord(rec))
+	}
+
+	if f.tmpl.Lookup(reportTemplate) != nil {
+		var b bytes.Buffer
+		if err := f.tmpl.ExecuteTemplate(&b, reportTemplate, records); err != nil {
+			return err
+		}
+		if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+			b.WriteByte('\n')
+		}
+		_, err := b.WriteTo(w)
+		return err
+	}
+
+	for _, rec := range records {
+		if err := f.tmpl.Execute(w, rec); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newTemplateRecord(rec *Record) *TemplateRecord {
+	t := &TemplateRecord{Record: rec}
+	switch {
+	case rec.Request != nil:
+		t.Subject = rec.Request.inner.Subject
+		t.Names = requestSANs(rec.Request.inner).names()
+	case rec.CRL != nil:
+		t.Issuer = rec.CRL.inner.Issuer
+		t.SerialNumber = rec.CRL.inner.Number
+		t.NotBefore = rec.CRL.inner.ThisUpdate
+		t.NotAfter = rec.CRL.inner.NextUpdate
+	case rec.Cert != nil:
+		inner := rec.Cert.inner
+		t.Subject = inner.Subject
+		t.Issuer = inner.Issuer
+		t.SerialNumber = inner.SerialNumber
+		t.NotBefore = inner.NotBefore
+		t.NotAfter = inner.NotAfter
+		t.Names = certSANs(inner).names()
+		t.Fingerprint = rec.Cert.fingerprint
+		t.X509 = inner
+	}
+	return t
+}
+
+// templateFuncs are helpers available in templates.
+var templateFuncs = template.FuncMap{
+	// duration formats a duration like the text output, e.g. "3.2 months"
+	"duration": func(d any) (string, error) {
+		v, err := toDuration(d)
+		return v.String(), err
+	},
+	// days returns whole days of a duration, negative for expired
+	// certificates
+	"days": func(d any) (int, error) {
+		v, err := toDuration(d)
+		return int(time.Duration(v) / (24 * time.Hour)), err
+	},
+	// date formats a time as YYYY-MM-DD
+	"date": func(t time.Time) string {
+		return t.Format("2006-01-02")
+	},
+	// fingerprint formats a fingerprint as hex, shortened to 16 digits if
+	// short is set
+	"fingerprint": func(fp Fingerprint, short ...bool) string {
+		if len(short) > 0 && short[0] {
+			return shortFingerprint(fp)
+		}
+		return fmt.Sprintf("%X", fp)
+	},
+	// name returns the common name or a fallback, like the header lines
+	"name": displayName,
+	// dn formats a name as an RFC 2253 distinguished name
+	"dn": func(name pkix.Name) string {
+		return name.String()
+	},
+	// field formats a record field like --fields, e.g. field . "not_after"
+	"field": func(rec *TemplateRecord, name string) (string, error) {
+		i := fieldIndex(name)
+		if i < 0 {
+			return "", fmt.Errorf("unknown field %q", name)
+		}
+		return Fields[i].Value(rec), nil
+	},
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+func toDuration(d any) (Duration, error) {
+	switch v := d.(type) {
+	case Duration:
+		return v, nil
+	case time.Duration:
+		return Duration(v), nil
+	default:
+		return 0, fmt.Errorf("not a duration: %T", d)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateFormat(t *testing.T) {
+	in, err := LoadInput("testdata/example.com.crt")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	req, err := LoadInput("testdata/example.com.csr")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	crl, err := LoadInput("testdata/ca.crl")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+
+	leaf := &Record{Cert: in.Bundle[0], Validity: Validity{ExpiresIn: Duration(45 * 24 * time.Hour)}}
+	expired := &Record{Cert: in.Bundle[0], Validity: Validity{ExpiresIn: Duration(-45 * 24 * time.Hour)}}
+	report := Report{leaf, {Request: req.Requests[0]}, {CRL: crl.CRLs[0]}}
+
+	tests := []struct {
+		name     string
+		template string
+		report   Report
+		want     string
+	}{
+		{
+			"fields",
+			"{{.Subject.CommonName}} {{.Validity.ExpiresIn}} {{date .NotAfter}}",
+			Report{leaf},
+			"example.com 1.5 months 2026-05-14\n",
+		},
+		{
+			"helpers",
+			`{{days .Validity.ExpiresIn}} {{name .Issuer}} {{join .Names ","}} {{len (fingerprint .Fingerprint)}} {{fingerprint .Fingerprint true | lower}}`,
+			Report{leaf},
+			"45 Cloudflare TLS Issuing ECC CA 3 example.com,*.example.com 64 " + strings.ToLower(shortFingerprint(leaf.Cert.fingerprint)) + "\n",
+		},
+		{
+			"request and CRL",
+			"{{name .Subject}}|{{name .Issuer}}",
+			report[1:],
+			"www.example.com|\n|Example Test CA\n",
+		},
+		{
+			"report",
+			`{{define "report"}}{{len .}}:{{range .}} {{.Subject.CommonName}}{{end}}{{end}}`,
+			report,
+			"3: example.com www.example.com \n",
+		},
+		{
+			"expired",
+			"{{.Validity.ExpiresIn}} {{days .Validity.ExpiresIn}}",
+			Report{expired},
+			"-1.5 months -45\n",
+		},
+	}
+	for _, tt := range tests {
+		f, err := NewTemplateFormatter(tt.template)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var b strings.Builder
+		if err := f.Format(&b, tt.report); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := NewTemplateFormatter("{{.Subject"); err == nil {
+		t.Errorf("invalid template didn't fail")
+	}
+	f, _ := NewTemplateFormatter("{{duration .Subject}}")
+	if err := f.Format(&strings.Builder{}, Report{leaf}); err == nil {
+		t.Errorf("duration of a name didn't fail")
+	}
+}
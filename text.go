@@ -1,12 +1,17 @@
 package main
 
 import (
+	"crypto/dsa"
 	"crypto/ecdsa"
 	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/asn1"
 	"fmt"
+	"io"
+	"net"
+	"net/url"
 	"strings"
 	"text/tabwriter"
 	"time"
@@ -16,59 +21,89 @@ const (
 	headerWidth = 80
 	ansiBold    = "\033[1m"
 	ansiGreen   = "\033[32m"
+	ansiYellow  = "\033[33m"
 	ansiRed     = "\033[31m"
 	ansiReset   = "\033[0m"
 
 	maxCompactSANs = 3
 	maxVerboseSANs = 20
+
+	maxCompactRevoked = 3
+	maxVerboseRevoked = 20
 )
 
 type TextFormatter struct {
 	Verbosity OutputLevel
 }
 
-func (f *TextFormatter) Format(report Report) (string, error) {
-	var s strings.Builder
-	w := tabwriter.NewWriter(&s, 0, 0, 1, ' ', 0)
+func (f *TextFormatter) Format(out io.Writer, report Report) error {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
 	for _, record := range report {
-		// Flush tabwriter before writing header directly to s,
+		// Flush tabwriter before writing header directly to out,
 		// so the header line isn't mangled by tab alignment.
 		if err := w.Flush(); err != nil {
-			return "", fmt.Errorf("tabwriter failed: %w", err)
+			return fmt.Errorf("tabwriter failed: %w", err)
 		}
-		f.formatHeader(&s, record)
+		f.formatHeader(out, record)
 		f.formatFields(w, record)
 	}
 
 	if err := w.Flush(); err != nil {
-		return "", fmt.Errorf("tabwriter failed: %w", err)
+		return fmt.Errorf("tabwriter failed: %w", err)
 	}
-
-	return s.String(), nil
+	return nil
 }
 
-func (f *TextFormatter) formatHeader(s *strings.Builder, record *Record) {
-	name := record.Cert.inner.Subject.CommonName
-	if name == "" {
-		// Some root CAs don't have a CN but OU
-		if len(record.Cert.inner.Subject.OrganizationalUnit) > 0 {
-			name = record.Cert.inner.Subject.OrganizationalUnit[0]
-		} else {
-			name = record.Cert.inner.Subject.String()
-		}
+func (f *TextFormatter) formatHeader(s io.Writer, record *Record) {
+	name := recordName(record)
+	status := printBool(record.Error == nil && !record.LintFailed())
+	if record.Error == nil && !record.LintFailed() && record.Validity.Status == StatusExpiring {
+		status = printStatus(StatusExpiring)
 	}
-
-	status := printBool(record.Error == nil)
 	prefix := fmt.Sprintf("--- %s%s%s %s ", ansiBold, name, ansiReset, status)
 	pad := max(headerWidth-len(prefix), 3)
 	fmt.Fprintf(s, "%s%s\n", prefix, strings.Repeat("-", pad))
 }
 
+// displayName returns a short human readable name for the header line.
+func displayName(name pkix.Name) string {
+	if name.CommonName != "" {
+		return name.CommonName
+	}
+
+	// Some root CAs don't have a CN but OU
+	if len(name.OrganizationalUnit) > 0 {
+		return name.OrganizationalUnit[0]
+	}
+	return name.String()
+}
+
+// recordName returns a short name for a record to use in messages.
+func recordName(rec *Record) string {
+	switch {
+	case rec.Request != nil:
+		return displayName(rec.Request.inner.Subject)
+	case rec.CRL != nil:
+		return displayName(rec.CRL.inner.Issuer)
+	default:
+		return displayName(rec.Cert.inner.Subject)
+	}
+}
+
 func (f *TextFormatter) formatFields(w *tabwriter.Writer, record *Record) {
+	switch {
+	case record.Request != nil:
+		f.formatRequestFields(w, record)
+		return
+	case record.CRL != nil:
+		f.formatCRLFields(w, record)
+		return
+	}
+
 	cert := record.Cert.inner
 
 	fmt.Fprintf(w, "Subject:\t%s\n", f.formatName(cert.Subject))
-	if sans := f.formatSANs(cert); sans != "" {
+	if sans := f.formatSANs(certSANs(cert)); sans != "" {
 		fmt.Fprintf(w, "SANs:\t%s\n", sans)
 	}
 
@@ -78,49 +113,198 @@ func (f *TextFormatter) formatFields(w *tabwriter.Writer, record *Record) {
 		fmt.Fprintf(w, "Error:\t%v\n", record.Error)
 	}
 
+	for i, finding := range record.Findings {
+		label := "Lint:"
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(w, "%s\t%s %s\n", label, printSeverity(finding.Severity), finding)
+	}
+
 	if f.Verbosity >= VerboseOutput {
 		fmt.Fprintf(w, "Not Before:\t%s %s\n", cert.NotBefore.String(), printBool(record.Validity.NotBeforeOK))
-		fmt.Fprintf(w, "Not After:\t%s %s\n", cert.NotAfter.String(), printBool(record.Validity.NotAfterOK))
+		notAfter := printBool(record.Validity.NotAfterOK)
+		if record.Validity.Status == StatusExpiring {
+			notAfter = printStatus(StatusExpiring)
+		}
+		fmt.Fprintf(w, "Not After:\t%s %s\n", cert.NotAfter.String(), notAfter)
 	} else {
 		fmt.Fprintf(w, "Valid:\t%s\n", f.formatValidity(record))
 	}
 
+	if record.CT != nil {
+		f.formatCT(w, record.CT)
+	}
+
 	if f.Verbosity >= VerboseOutput {
 		fmt.Fprintf(w, "Fingerprint:\t%X\n", record.Cert.fingerprint)
-		fmt.Fprintf(w, "Key:\t%s\n", formatKeyInfo(cert))
-		fmt.Fprintf(w, "Signature:\t%s\n", cert.SignatureAlgorithm)
+	}
+
+	// Weak keys and signatures are shown regardless of verbosity
+	if f.Verbosity >= VerboseOutput || record.KeyWarning != "" {
+		fmt.Fprintf(w, "Key:\t%s%s\n", formatKeyInfo(cert.PublicKey), printWarning(record.KeyWarning))
+	}
+	if f.Verbosity >= VerboseOutput || record.SignatureWarning != "" {
+		fmt.Fprintf(w, "Signature:\t%s%s\n", cert.SignatureAlgorithm, printWarning(record.SignatureWarning))
 	}
 
 	if f.Verbosity >= FullOutput {
 		if ku := formatKeyUsage(cert.KeyUsage); ku != "" {
 			fmt.Fprintf(w, "Key Usage:\t%s\n", ku)
 		}
-		if eku := formatExtKeyUsage(cert.ExtKeyUsage); eku != "" {
+		if eku := formatExtKeyUsage(cert.ExtKeyUsage, cert.UnknownExtKeyUsage); eku != "" {
 			fmt.Fprintf(w, "Ext Key Usage:\t%s\n", eku)
 		}
+		for _, fl := range extensionFields(cert) {
+			label := fl.label
+			if label != "" {
+				label += ":"
+			}
+			fmt.Fprintf(w, "%s\t%s\n", label, fl.value)
+		}
 	}
 
 	fmt.Fprintf(w, "\n")
 }
 
-func (f *TextFormatter) formatSANs(cert *x509.Certificate) string {
-	sans := f.collectSANs(cert)
+// formatRequestFields prints a certificate request. Key and signature are
+// always shown since they are what a CSR is reviewed for.
+func (f *TextFormatter) formatRequestFields(w *tabwriter.Writer, record *Record) {
+	req := record.Request.inner
 
-	if len(sans) == 0 {
-		return ""
+	fmt.Fprintf(w, "Subject:\t%s\n", f.formatName(req.Subject))
+	if sans := f.formatSANs(requestSANs(req)); sans != "" {
+		fmt.Fprintf(w, "SANs:\t%s\n", sans)
 	}
 
-	var maxSANs int
-	switch f.Verbosity {
-	case FullOutput:
-		maxSANs = len(sans)
-	case VerboseOutput:
-		maxSANs = maxVerboseSANs
-	default:
-		maxSANs = maxCompactSANs
+	if record.Error != nil {
+		fmt.Fprintf(w, "Error:\t%v\n", record.Error)
+	}
+
+	fmt.Fprintf(w, "Key:\t%s%s\n", formatKeyInfo(req.PublicKey), printWarning(record.KeyWarning))
+	fmt.Fprintf(w, "Signature:\t%s %s%s\n", req.SignatureAlgorithm, printBool(record.Error == nil), printWarning(record.SignatureWarning))
+
+	if ku, err := record.Request.KeyUsage(); err != nil {
+		fmt.Fprintf(w, "Key Usage:\t%v\n", err)
+	} else if s := formatKeyUsage(ku); s != "" {
+		fmt.Fprintf(w, "Key Usage:\t%s\n", s)
+	}
+
+	if eku, unknown, err := record.Request.ExtKeyUsage(); err != nil {
+		fmt.Fprintf(w, "Ext Key Usage:\t%v\n", err)
+	} else if s := formatExtKeyUsage(eku, unknown); s != "" {
+		fmt.Fprintf(w, "Ext Key Usage:\t%s\n", s)
+	}
+
+	if f.Verbosity >= VerboseOutput {
+		if exts := formatExtensionList(req.Extensions); exts != "" {
+			fmt.Fprintf(w, "Extensions:\t%s\n", exts)
+		}
+	}
+
+	fmt.Fprintf(w, "\n")
+}
+
+// formatCRLFields prints a revocation list with its revoked entries.
+func (f *TextFormatter) formatCRLFields(w *tabwriter.Writer, record *Record) {
+	crl := record.CRL.inner
+
+	fmt.Fprintf(w, "Issuer:\t%s\n", f.formatName(crl.Issuer))
+
+	if record.Error != nil {
+		fmt.Fprintf(w, "Error:\t%v\n", record.Error)
+	}
+
+	if f.Verbosity >= VerboseOutput {
+		fmt.Fprintf(w, "This Update:\t%s %s\n", crl.ThisUpdate.String(), printBool(record.Validity.NotBeforeOK))
+		if !crl.NextUpdate.IsZero() {
+			fmt.Fprintf(w, "Next Update:\t%s %s\n", crl.NextUpdate.String(), printBool(record.Validity.NotAfterOK))
+		}
+	} else {
+		fmt.Fprintf(w, "Valid:\t%s\n", f.formatCRLValidity(record))
+	}
+
+	if crl.Number != nil {
+		fmt.Fprintf(w, "CRL Number:\t%s\n", crl.Number)
+	}
+
+	if record.CRLIssuer != nil {
+		fmt.Fprintf(w, "Signature:\t%s %s\n", crl.SignatureAlgorithm, printBool(true))
+	} else {
+		fmt.Fprintf(w, "Signature:\t%s (not verified)\n", crl.SignatureAlgorithm)
+	}
+
+	entries := crl.RevokedCertificateEntries
+	fmt.Fprintf(w, "Revoked:\t%d certificates\n", len(entries))
+	n := f.listLimit(len(entries), maxCompactRevoked, maxVerboseRevoked)
+	for _, e := range entries[:n] {
+		fmt.Fprintf(w, "\t%X\t%s\t%s\n", e.SerialNumber, e.RevocationTime.Format("2006-01-02"), revocationReason(e.ReasonCode))
+	}
+	if extra := len(entries) - n; extra > 0 {
+		fmt.Fprintf(w, "\t(+%d more)\n", extra)
+	}
+
+	fmt.Fprintf(w, "\n")
+}
+
+// formatCT prints the number of valid SCTs and, in verbose mode, every SCT.
+func (f *TextFormatter) formatCT(w *tabwriter.Writer, ct *CTResult) {
+	fmt.Fprintf(w, "CT:\t%d of %d SCTs valid, %d operators %s\n", ct.Valid(), len(ct.SCTs), ct.Operators(), printBool(ct.Compliant()))
+	if f.Verbosity < VerboseOutput {
+		return
+	}
+
+	for _, c := range ct.SCTs {
+		parts := []string{c.SCT.Source, formatLogID(c.SCT.LogID), c.SCT.Timestamp.Format("2006-01-02 15:04:05"), c.SCT.Algorithm()}
+		if c.Log != nil {
+			parts = append(parts, c.Log.Description)
+		}
+		parts = append(parts, printBool(c.Error == nil))
+		if c.Error != nil {
+			parts = append(parts, c.Error.Error())
+		}
+		fmt.Fprintf(w, "\t%s\n", strings.Join(parts, " "))
+	}
+}
+
+// subjectAltNames holds the SAN fields shared by certificates and requests.
+type subjectAltNames struct {
+	DNSNames       []string
+	IPAddresses    []net.IP
+	EmailAddresses []string
+	URIs           []*url.URL
+}
+
+func certSANs(cert *x509.Certificate) subjectAltNames {
+	return subjectAltNames{cert.DNSNames, cert.IPAddresses, cert.EmailAddresses, cert.URIs}
+}
+
+func requestSANs(req *x509.CertificateRequest) subjectAltNames {
+	return subjectAltNames{req.DNSNames, req.IPAddresses, req.EmailAddresses, req.URIs}
+}
+
+// names lists the SANs in the form accepted by CertOptions.
+func (s subjectAltNames) names() []string {
+	var names []string
+	names = append(names, s.DNSNames...)
+	for _, ip := range s.IPAddresses {
+		names = append(names, ip.String())
+	}
+	names = append(names, s.EmailAddresses...)
+	for _, uri := range s.URIs {
+		names = append(names, uri.String())
+	}
+	return names
+}
+
+func (f *TextFormatter) formatSANs(names subjectAltNames) string {
+	sans := f.collectSANs(names)
+
+	if len(sans) == 0 {
+		return ""
 	}
 
-	n := min(len(sans), maxSANs)
+	n := f.listLimit(len(sans), maxCompactSANs, maxVerboseSANs)
 	s := strings.Join(sans[:n], ", ")
 	if extra := len(sans) - n; extra > 0 {
 		s += fmt.Sprintf(" (+%d more)", extra)
@@ -129,39 +313,53 @@ func (f *TextFormatter) formatSANs(cert *x509.Certificate) string {
 	return s
 }
 
-func (f *TextFormatter) collectSANs(cert *x509.Certificate) []string {
+// listLimit returns how many of n list items to print for the verbosity level.
+func (f *TextFormatter) listLimit(n, compact, verbose int) int {
+	switch f.Verbosity {
+	case FullOutput:
+		return n
+	case VerboseOutput:
+		return min(n, verbose)
+	default:
+		return min(n, compact)
+	}
+}
+
+func (f *TextFormatter) collectSANs(names subjectAltNames) []string {
 	if f.Verbosity == CompactOutput {
-		return cert.DNSNames
+		return names.DNSNames
 	}
 
 	var sans []string
-	for _, dns := range cert.DNSNames {
+	for _, dns := range names.DNSNames {
 		sans = append(sans, "DNS:"+dns)
 	}
 
-	for _, ip := range cert.IPAddresses {
+	for _, ip := range names.IPAddresses {
 		sans = append(sans, "IP:"+ip.String())
 	}
 
-	for _, email := range cert.EmailAddresses {
+	for _, email := range names.EmailAddresses {
 		sans = append(sans, "Email:"+email)
 	}
 
-	for _, uri := range cert.URIs {
+	for _, uri := range names.URIs {
 		sans = append(sans, "URI:"+uri.String())
 	}
 
 	return sans
 }
 
-func formatKeyInfo(cert *x509.Certificate) string {
-	switch pub := cert.PublicKey.(type) {
+func formatKeyInfo(pub any) string {
+	switch pub := pub.(type) {
 	case *rsa.PublicKey:
 		return fmt.Sprintf("RSA %d bits", pub.N.BitLen())
 	case *ecdsa.PublicKey:
 		return fmt.Sprintf("ECDSA %s", pub.Curve.Params().Name)
 	case ed25519.PublicKey:
 		return "Ed25519"
+	case *dsa.PublicKey:
+		return fmt.Sprintf("DSA %d bits", pub.P.BitLen())
 	default:
 		return fmt.Sprintf("%T", pub)
 	}
@@ -207,7 +405,7 @@ var extKeyUsageNames = map[x509.ExtKeyUsage]string{
 	x509.ExtKeyUsageNetscapeServerGatedCrypto:  "Netscape Server Gated Crypto",
 }
 
-func formatExtKeyUsage(eku []x509.ExtKeyUsage) string {
+func formatExtKeyUsage(eku []x509.ExtKeyUsage, unknown []asn1.ObjectIdentifier) string {
 	var names []string
 	for _, usage := range eku {
 		if name, ok := extKeyUsageNames[usage]; ok {
@@ -216,6 +414,9 @@ func formatExtKeyUsage(eku []x509.ExtKeyUsage) string {
 			names = append(names, fmt.Sprintf("Unknown(%d)", usage))
 		}
 	}
+	for _, oid := range unknown {
+		names = append(names, oid.String())
+	}
 	return strings.Join(names, ", ")
 }
 
@@ -224,10 +425,25 @@ func (f *TextFormatter) formatValidity(rec *Record) string {
 	inner := rec.Cert.inner
 
 	if v.ExpiresIn < 0 {
-		return fmt.Sprintf("%v, expired on %v %s", v.Period, inner.NotAfter.Format("2006-01-02"), printBool(v.OK))
+		return fmt.Sprintf("%v, expired on %v %s", v.Period, inner.NotAfter.Format("2006-01-02"), printStatus(v.Status))
 	}
 
-	return fmt.Sprintf("%v, expires in %v (%v) %s", v.Period, v.ExpiresIn, inner.NotAfter.Format("2006-01-02"), printBool(v.OK))
+	return fmt.Sprintf("%v, expires in %v (%v) %s", v.Period, v.ExpiresIn, inner.NotAfter.Format("2006-01-02"), printStatus(v.Status))
+}
+
+func (f *TextFormatter) formatCRLValidity(rec *Record) string {
+	v := rec.Validity
+	inner := rec.CRL.inner
+
+	if inner.NextUpdate.IsZero() {
+		return fmt.Sprintf("since %v, no next update %s", inner.ThisUpdate.Format("2006-01-02"), printBool(v.OK))
+	}
+
+	if v.ExpiresIn < 0 {
+		return fmt.Sprintf("%v, next update was due on %v %s", v.Period, inner.NextUpdate.Format("2006-01-02"), printBool(v.OK))
+	}
+
+	return fmt.Sprintf("%v, next update in %v (%v) %s", v.Period, v.ExpiresIn, inner.NextUpdate.Format("2006-01-02"), printBool(v.OK))
 }
 
 func (f *TextFormatter) formatName(name pkix.Name) string {
@@ -249,6 +465,36 @@ func (f *TextFormatter) formatName(name pkix.Name) string {
 	return strings.Join(s, ", ")
 }
 
+// printStatus prints validity status like printBool with a warning for
+// expiring certificates.
+func printStatus(s ValidityStatus) string {
+	if s == StatusExpiring {
+		return ansiYellow + "[WARN]" + ansiReset
+	}
+	return printBool(s == StatusOK)
+}
+
+// printWarning returns a colored warning to append to a field value or an
+// empty string if there's no warning.
+func printWarning(warning string) string {
+	if warning == "" {
+		return ""
+	}
+	return " " + ansiYellow + "[WARN] " + warning + ansiReset
+}
+
+// printSeverity prints a lint finding severity in the style of printBool.
+func printSeverity(s Severity) string {
+	switch s {
+	case SeverityError:
+		return ansiRed + "[ERR]" + ansiReset
+	case SeverityWarning:
+		return ansiYellow + "[WARN]" + ansiReset
+	default:
+		return "[INFO]"
+	}
+}
+
 func printBool(b bool) string {
 	if b {
 		return ansiGreen + "[OK]" + ansiReset
@@ -256,7 +502,8 @@ func printBool(b bool) string {
 	return ansiRed + "[ERR]" + ansiReset
 }
 
-// Duration provides custom time.Duration string serialization
+// Duration provides custom time.Duration string serialization. Negative
+// durations, like expiry of expired certificates, get a minus sign.
 type Duration time.Duration
 
 func (d Duration) String() string {
@@ -267,16 +514,22 @@ func (d Duration) String() string {
 		year  = 365 * day
 	)
 
+	// Negating the hours rather than d as -d overflows for the minimum
+	// duration that time.Sub returns for far away times
 	h := time.Duration(d).Hours()
+	sign := ""
+	if h < 0 {
+		sign, h = "-", -h
+	}
 	switch {
 	case h >= year:
-		return fmt.Sprintf("%.1f years", h/year)
+		return fmt.Sprintf("%s%.1f years", sign, h/year)
 	case h >= month:
-		return fmt.Sprintf("%.1f months", h/month)
+		return fmt.Sprintf("%s%.1f months", sign, h/month)
 	case h >= week:
-		return fmt.Sprintf("%.1f weeks", h/week)
+		return fmt.Sprintf("%s%.1f weeks", sign, h/week)
 	case h >= day:
-		return fmt.Sprintf("%.1f days", h/day)
+		return fmt.Sprintf("%s%.1f days", sign, h/day)
 	default:
 		return time.Duration(d).String()
 	}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDurationString(t *testing.T) {
+	tests := []struct {
+		d    Duration
+		want string
+	}{
+		{Duration(90 * time.Minute), "1h30m0s"},
+		{Duration(3 * 24 * time.Hour), "3.0 days"},
+		{Duration(-45 * 24 * time.Hour), "-1.5 months"},
+		{Duration(-90 * time.Minute), "-1h30m0s"},
+		{Duration(math.MinInt64), "-292.5 years"},
+		{Duration(math.MaxInt64), "292.5 years"},
+	}
+	for _, tt := range tests {
+		if got := tt.d.String(); got != tt.want {
+			t.Errorf("Duration(%d).String() == %q, want %q", int64(tt.d), got, tt.want)
+		}
+	}
+}
@@ -10,6 +10,26 @@ type VerifyOptions struct {
 	Time          time.Time
 	Roots         Bundle
 	Intermediates Bundle
+	CRLIssuers    Bundle
+
+	// DNSName is checked against the leaf certificate if set
+	DNSName string
+
+	// KeyUsages the leaf must be valid for, server auth if empty
+	KeyUsages []x509.ExtKeyUsage
+
+	// WarnWithin marks certificates expiring within it as expiring
+	WarnWithin time.Duration
+
+	// LintRules are run against every certificate, none if empty
+	LintRules []LintRule
+
+	// CryptoPolicy flags weak keys and signatures if set
+	CryptoPolicy *CryptoPolicy
+
+	// CTLogs are used to verify SCTs of the leaf certificate. SCTs aren't
+	// checked if nil.
+	CTLogs CTLogList
 }
 
 // Verify validates a certificate bundle and returns a report with results for each certificate.
@@ -20,7 +40,12 @@ func Verify(bundle Bundle, opts *VerifyOptions) (Report, error) {
 	// from leaf.
 	var s int // start of the valid chain
 	for s = 0; s < len(bundle); s++ {
-		_, err := verifyChain(bundle[s:], opts)
+		dnsName := ""
+		if s == 0 {
+			dnsName = opts.DNSName
+		}
+
+		_, err := verifyChain(bundle[s:], dnsName, opts)
 		if err != nil {
 			records = append(records, NewRecord(bundle[s], err, opts))
 			// Continue with the smaller chain
@@ -37,9 +62,41 @@ func Verify(bundle Bundle, opts *VerifyOptions) (Report, error) {
 	return Report(records), nil
 }
 
+// VerifyInput validates certificates, certificate requests and revocation lists
+// decoded from a source. Requests and CRLs are reported after the certificates.
+func VerifyInput(in *Input, opts *VerifyOptions) (Report, error) {
+	if in.ServerName != "" && opts.DNSName == "" {
+		o := *opts
+		o.DNSName = in.ServerName
+		opts = &o
+	}
+
+	report, err := Verify(in.Bundle, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.CTLogs != nil && len(in.Bundle) > 0 {
+		leaf := in.Bundle[0]
+		issuer := issuerOf(leaf, in.Bundle[1:], opts.Intermediates, opts.Roots)
+		report[0].CT = CheckCT(leaf, issuer, in.SCTs, opts.CTLogs)
+	}
+
+	for _, req := range in.Requests {
+		report = append(report, NewRequestRecord(req, opts))
+	}
+
+	for _, crl := range in.CRLs {
+		report = append(report, NewCRLRecord(crl, in.Bundle, opts))
+	}
+
+	return report, nil
+}
+
 // verifyChain verifies the first certificate in the chain using other certs
-// as intermediates.
-func verifyChain(chain []*Certificate, opts *VerifyOptions) ([][]*x509.Certificate, error) {
+// as intermediates. The DNS name is checked only if the chain starts with the
+// leaf.
+func verifyChain(chain []*Certificate, dnsName string, opts *VerifyOptions) ([][]*x509.Certificate, error) {
 	if len(chain) == 0 {
 		return nil, nil
 	}
@@ -65,8 +122,10 @@ func verifyChain(chain []*Certificate, opts *VerifyOptions) ([][]*x509.Certifica
 	}
 
 	return chain[0].inner.Verify(x509.VerifyOptions{
+		DNSName:       dnsName,
 		Intermediates: intermediates,
 		Roots:         roots,
 		CurrentTime:   opts.Time,
+		KeyUsages:     opts.KeyUsages,
 	})
 }
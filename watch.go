@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Watcher re-checks sources on an interval and prints reports only when the
+// leaf, the chain or the status changes. File sources are also re-checked as
+// soon as they are modified. Directories and globs are expanded to the files
+// in them on every round, so that added files are checked too.
+type Watcher struct {
+	Sources   []string
+	Interval  time.Duration
+	Options   VerifyOptions
+	Formatter Formatter
+	Out       io.Writer
+
+	// Now returns the current time, time.Now if nil
+	Now func() time.Time
+
+	state map[string]*watchState
+}
+
+// watchState is what's compared between checks of a source.
+type watchState struct {
+	checked time.Time
+	modTime time.Time
+
+	chain  []Fingerprint
+	status string
+}
+
+// Run checks sources until ctx is canceled.
+func (w *Watcher) Run(ctx context.Context) error {
+	ticker := time.NewTicker(min(w.Interval, time.Second))
+	defer ticker.Stop()
+
+	for {
+		sources, failed, err := w.expand()
+		if err != nil {
+			return err
+		}
+		for _, s := range failed {
+			if err := w.update(s, w.now(), time.Time{}); err != nil {
+				return err
+			}
+		}
+		for _, source := range sources {
+			if err := w.checkIfDue(source); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// expand returns the sources with directories and globs replaced by the files
+// in them, and the paths that couldn't be read.
+func (w *Watcher) expand() (sources []string, failed []Section, err error) {
+	for _, source := range w.Sources {
+		if !IsMultiSource(source) {
+			sources = append(sources, source)
+			continue
+		}
+		files, f, err := ExpandSource(source)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, files...)
+		failed = append(failed, f...)
+	}
+	return sources, failed, nil
+}
+
+func (w *Watcher) now() time.Time {
+	if w.Now != nil {
+		return w.Now()
+	}
+	return time.Now()
+}
+
+// checkIfDue checks the source if the interval has passed or the file was
+// modified since the last check.
+func (w *Watcher) checkIfDue(source string) error {
+	now := w.now()
+	var modTime time.Time
+	if info, err := os.Stat(source); err == nil {
+		modTime = info.ModTime()
+	}
+
+	prev := w.state[source]
+	if prev != nil && now.Sub(prev.checked) < w.Interval && modTime.Equal(prev.modTime) {
+		return nil
+	}
+
+	opts := w.Options
+	opts.Time = now
+	return w.update(checkSource(source, &opts), now, modTime)
+}
+
+// update records the state of a checked source and prints what changed since
+// the previous check.
+func (w *Watcher) update(section Section, now, modTime time.Time) error {
+	if w.state == nil {
+		w.state = make(map[string]*watchState)
+	}
+
+	source := section.Source
+	prev := w.state[source]
+	cur := &watchState{
+		checked: now,
+		modTime: modTime,
+		status:  watchStatus(section),
+	}
+	for _, rec := range section.Report {
+		if rec.Cert != nil {
+			cur.chain = append(cur.chain, rec.Cert.fingerprint)
+		}
+	}
+	w.state[source] = cur
+
+	changes := describeChanges(prev, cur)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(w.Out, "%s %s: %s\n", now.Format(time.RFC3339), source, strings.Join(changes, ", "))
+	if section.Error != nil {
+		return nil
+	}
+
+	if err := w.Formatter.Format(w.Out, section.Report); err != nil {
+		return fmt.Errorf("formatting: %w", err)
+	}
+	return nil
+}
+
+// watchStatus summarizes a section as a short status word.
+func watchStatus(s Section) string {
+	if s.Error != nil {
+		return "load failed: " + s.Error.Error()
+	}
+
+	switch s.Report.ExitCode() {
+	case ExitVerifyFailed:
+		return "verification failed"
+	case ExitExpiring:
+		return "expiring"
+	default:
+		return "ok"
+	}
+}
+
+// describeChanges lists differences between two states. The first state is
+// described as a whole.
+func describeChanges(prev, cur *watchState) []string {
+	if prev == nil {
+		changes := []string{cur.status}
+		if len(cur.chain) > 0 {
+			changes = append(changes, fmt.Sprintf("leaf %s", shortFingerprint(cur.chain[0])))
+		}
+		return changes
+	}
+
+	var changes []string
+
+	prevLeaf, curLeaf := leafFingerprint(prev.chain), leafFingerprint(cur.chain)
+	if prevLeaf != curLeaf {
+		changes = append(changes, fmt.Sprintf("leaf %s -> %s", prevLeaf, curLeaf))
+	}
+
+	if len(prev.chain) > 0 && len(cur.chain) > 0 && !slices.Equal(prev.chain[1:], cur.chain[1:]) {
+		changes = append(changes, fmt.Sprintf("chain changed (%d -> %d certificates)", len(prev.chain), len(cur.chain)))
+	}
+
+	if prev.status != cur.status {
+		changes = append(changes, fmt.Sprintf("status %s -> %s", prev.status, cur.status))
+	}
+
+	return changes
+}
+
+func leafFingerprint(chain []Fingerprint) string {
+	if len(chain) == 0 {
+		return "none"
+	}
+	return shortFingerprint(chain[0])
+}
+
+// shortFingerprint returns first bytes of the fingerprint for log lines.
+func shortFingerprint(fp Fingerprint) string {
+	return fmt.Sprintf("%X", fp[:8])
+}
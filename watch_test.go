@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatcher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.pem")
+	copyFile := func(name string, modTime time.Time) {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	now := time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC)
+	var out strings.Builder
+	w := &Watcher{
+		Sources:   []string{path},
+		Interval:  time.Hour,
+		Formatter: &TextFormatter{},
+		Out:       &out,
+		Now:       func() time.Time { return now },
+	}
+
+	check := func() string {
+		out.Reset()
+		if err := w.checkIfDue(path); err != nil {
+			t.Fatalf("check: %v", err)
+		}
+		return out.String()
+	}
+
+	copyFile("example.com.crt", now)
+	if got := check(); !strings.Contains(got, ": ok, leaf 7A70788FE1F5A90E\n") {
+		t.Errorf("first check output:\n%s", got)
+	}
+
+	// Interval has passed, but nothing changed
+	now = now.Add(2 * time.Hour)
+	if got := check(); got != "" {
+		t.Errorf("unchanged check output:\n%s", got)
+	}
+
+	// File is modified before the interval passes
+	copyFile("ca.crt", now.Add(time.Minute))
+	now = now.Add(time.Minute)
+	got := check()
+	for _, want := range []string{
+		"leaf 7A70788FE1F5A90E -> E983B82FDCEC7DFC",
+		"chain changed (3 -> 1 certificates)",
+		"status ok -> verification failed",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("changed check output doesn't contain %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestWatcherDirectory(t *testing.T) {
+	dir := t.TempDir()
+	copyFile := func(name, to string) string {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		path := filepath.Join(dir, to)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		return path
+	}
+
+	var out strings.Builder
+	w := &Watcher{
+		Sources:   []string{dir, filepath.Join(dir, "*.pem")},
+		Interval:  time.Hour,
+		Formatter: &TextFormatter{},
+		Out:       &out,
+		Now:       func() time.Time { return time.Date(2026, 2, 16, 12, 0, 0, 0, time.UTC) },
+	}
+
+	// Run checks every source once before it sees the canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	run := func() string {
+		out.Reset()
+		if err := w.Run(ctx); err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return out.String()
+	}
+
+	first := copyFile("example.com.crt", "example.crt")
+	if got := run(); !strings.Contains(got, first+": ok") {
+		t.Errorf("first round output:\n%s", got)
+	}
+
+	// Files added later are picked up, files matched twice are checked once
+	added := copyFile("ca.crt", "ca.pem")
+	got := run()
+	if strings.Contains(got, first) || strings.Count(got, added+": ") != 1 {
+		t.Errorf("second round output:\n%s", got)
+	}
+}